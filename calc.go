@@ -3,6 +3,7 @@ package rview
 import (
 	"math"
 	"reflect"
+	"strconv"
 
 	"github.com/TinyWisp/rview/ddl"
 	"github.com/TinyWisp/rview/tperr"
@@ -11,115 +12,238 @@ import (
 // a function that get a variable according to its name
 type VarGetter func(name string) (interface{}, error)
 
+// an expression compiled into a closure, which can be evaluated many times without walking the expression tree again
+type CompiledExp func(varGetter VarGetter) (*ddl.Exp, error)
+
 func CalcExp(exp *ddl.Exp, varGetter VarGetter) (*ddl.Exp, error) {
+	return CompileExp(exp)(varGetter)
+}
+
+// the operators whose operands are both evaluated before the operation
+var binaryOperators = map[string]func(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error){
+	"+":  calcPlus,
+	"*":  calcTimes,
+	"/":  calcDivision,
+	"%":  calcModulo,
+	">":  calcGreater,
+	">=": calcGreaterOrEqual,
+	"<":  calcLess,
+	"<=": calcLessOrEqual,
+	"==": calcEqual,
+	"!=": calcNotEqual,
+	"!":  calcLogicalNot,
+}
+
+func CompileExp(exp *ddl.Exp) CompiledExp {
 	if exp == nil {
-		return &ddl.Exp{
+		nilExp := &ddl.Exp{
 			Type: ddl.ExpNil,
-		}, nil
+		}
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return nilExp, nil
+		}
+	}
+
+	run := compileNode(exp)
+
+	// an expression without any variable or function, like 60 * 60 * 24, is evaluated only once.
+	// if it fails, the error is left to be raised when it is evaluated.
+	if exp.Type == ddl.ExpCalc && isConstantExp(exp) {
+		if res, err := run(nil); err == nil {
+			return func(varGetter VarGetter) (*ddl.Exp, error) {
+				return res, nil
+			}
+		}
 	}
 
-	res := exp
-	err := error(nil)
+	return run
+}
+
+func compileNode(exp *ddl.Exp) CompiledExp {
+	run := CompiledExp(nil)
 
 	switch exp.Type {
 	case ddl.ExpVar:
-		res, err = calcVar(exp, varGetter)
+		run = func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcVar(exp, varGetter)
+		}
 
 	case ddl.ExpFunc:
-		res, err = calcFunc(exp, varGetter)
+		params := compileExps(exp.FuncParams)
+		run = func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcFunc(exp, params, varGetter)
+		}
 
-	case ddl.ExpCalc:
-		left, lerr := CalcExp(exp.Left, varGetter)
-		if lerr != nil {
-			res = nil
-			err = lerr
-			break
+	case ddl.ExpArray:
+		items := compileExps(exp.Array)
+		run = func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcArray(items, varGetter)
 		}
 
-		right, rerr := CalcExp(exp.Right, varGetter)
-		if rerr != nil {
-			res = nil
-			err = rerr
-			break
+	case ddl.ExpMap:
+		keys := make([]string, 0, len(exp.Map))
+		valExps := make([]*ddl.Exp, 0, len(exp.Map))
+		for key, valExp := range exp.Map {
+			keys = append(keys, key)
+			valExps = append(valExps, valExp)
+		}
+		vals := compileExps(valExps)
+		run = func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcMap(keys, vals, varGetter)
 		}
 
-		switch exp.Operator {
-		case "+":
-			res, err = calcPlus(left, right)
+	case ddl.ExpCalc:
+		run = compileCalc(exp)
 
-		case "-":
-			res, err = calcMinus(left, right)
+	// a literal is the result itself
+	default:
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return exp, nil
+		}
+	}
 
-		case "*":
-			res, err = calcTimes(left, right)
+	return func(varGetter VarGetter) (*ddl.Exp, error) {
+		res, err := run(varGetter)
+		if err != nil {
+			if _, ok := err.(*ddl.DdlError); !ok {
+				err = ddl.WrapDdlError("", exp.Pos, err)
+			}
+			return nil, err
+		}
+		return res, nil
+	}
+}
 
-		case "/":
-			res, err = calcDivision(left, right)
+func compileExps(exps []*ddl.Exp) []CompiledExp {
+	runs := make([]CompiledExp, 0, len(exps))
+	for _, exp := range exps {
+		runs = append(runs, CompileExp(exp))
+	}
 
-		case ">":
-			res, err = calcGreater(left, right)
+	return runs
+}
 
-		case ">=":
-			res, err = calcGreaterOrEqual(left, right)
+func compileCalc(exp *ddl.Exp) CompiledExp {
+	switch {
+	// these operators only evaluate the operands they need
+	case exp.Operator == "&&" || exp.Operator == "||":
+		left, right := CompileExp(exp.Left), CompileExp(exp.Right)
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcLogical(exp, left, right, varGetter)
+		}
 
-		case "<":
-			res, err = calcLess(left, right)
+	case exp.Operator == "?":
+		condition, left, right := CompileExp(exp.TenaryCondition), CompileExp(exp.Left), CompileExp(exp.Right)
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcTernary(exp, condition, left, right, varGetter)
+		}
 
-		case "<=":
-			res, err = calcLessOrEqual(left, right)
+	case exp.Operator == "??":
+		left, right := CompileExp(exp.Left), CompileExp(exp.Right)
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcCoalesce(left, right, varGetter)
+		}
 
-		case "==":
-			res, err = calcEqual(left, right)
+	case exp.Operator == "|":
+		left, params := CompileExp(exp.Left), compileExps(exp.Right.FuncParams)
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return calcFilter(exp, left, params, varGetter)
+		}
 
-		case "!=":
-			res, err = calcNotEqual(left, right)
+	case isMemberAccess(exp.Operator):
+		member := compileMember(exp)
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			res, _, err := member(varGetter)
+			return res, err
+		}
+	}
 
-		case "&&":
-			res, err = calcLogicalAnd(left, right)
+	left, right := CompileExp(exp.Left), CompileExp(exp.Right)
+	operate, ok := binaryOperators[exp.Operator]
+	if exp.Operator == "-" && exp.Left == nil {
+		operate = func(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
+			return calcNegative(right)
+		}
+	} else if exp.Operator == "-" {
+		operate = calcMinus
+	} else if !ok {
+		return func(varGetter VarGetter) (*ddl.Exp, error) {
+			return nil, tperr.NewTypedError("calc.unsupportedOperator", exp.Operator)
+		}
+	}
 
-		case "||":
-			res, err = calcLogicalOr(left, right)
+	return func(varGetter VarGetter) (*ddl.Exp, error) {
+		lres, err := left(varGetter)
+		if err != nil {
+			return nil, err
+		}
 
-		case "!":
-			res, err = calcLogicalNot(left, right)
+		rres, err := right(varGetter)
+		if err != nil {
+			return nil, err
+		}
 
-		case "?":
-			condition, terr := CalcExp(exp.TenaryCondition, varGetter)
-			if terr != nil {
-				res = nil
-				err = terr
-			} else {
-				res, err = calcTernaryCondition(condition, left, right)
-			}
+		return operate(lres, rres)
+	}
+}
 
-		case ".":
-			res, err = calcDot(left, right)
+// whether an expression is made of literals and operators only
+func isConstantExp(exp *ddl.Exp) bool {
+	if exp == nil {
+		return true
+	}
 
-		case "[":
-			res, err = calcSquareBracket(left, right)
+	switch exp.Type {
+	case ddl.ExpStr, ddl.ExpInt, ddl.ExpFloat, ddl.ExpBool, ddl.ExpNil:
+		return true
 
-		default:
-			res = nil
-			err = tperr.NewTypedError("calc.unsupportedOperator", exp.Operator)
+	case ddl.ExpCalc:
+		if _, ok := binaryOperators[exp.Operator]; !ok &&
+			exp.Operator != "-" && exp.Operator != "&&" && exp.Operator != "||" && exp.Operator != "?" && exp.Operator != "??" {
+			return false
 		}
+		return isConstantExp(exp.Left) && isConstantExp(exp.Right) && (exp.Operator != "?" || isConstantExp(exp.TenaryCondition))
 	}
 
-	if err != nil {
-		if terr, ok := err.(*tperr.TypedError); ok {
-			err = ddl.NewDdlError("", exp.Pos, terr.GetEtype(), terr.GetVars()...)
-		} else if _, ok := err.(*ddl.DdlError); !ok {
-			err = ddl.NewDdlError("", exp.Pos, err.Error())
-		}
+	return false
+}
+
+// integer arithmetic reports an overflow, instead of wrapping around silently
+func addInt(a int64, b int64) (int64, bool) {
+	c := a + b
+	return c, (b > 0 && c < a) || (b < 0 && c > a)
+}
+
+func subInt(a int64, b int64) (int64, bool) {
+	c := a - b
+	return c, (b > 0 && c > a) || (b < 0 && c < a)
+}
+
+func mulInt(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, false
 	}
+	c := a * b
+	return c, c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64)
+}
 
-	return res, err
+// the string form of a number when it is concatenated to a string
+func numToStr(exp *ddl.Exp) string {
+	if exp.Type == ddl.ExpInt {
+		return strconv.FormatInt(exp.Int, 10)
+	}
+	return strconv.FormatFloat(exp.Float, 'g', -1, 64)
 }
 
 func calcPlus(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 	if left.Type == ddl.ExpInt && right.Type == ddl.ExpInt {
+		sum, overflow := addInt(left.Int, right.Int)
+		if overflow {
+			return nil, tperr.NewTypedError("calc.integerOverflow", left.Int, "+", right.Int)
+		}
 		return &ddl.Exp{
 			Type: ddl.ExpInt,
-			Int:  left.Int + right.Int,
+			Int:  sum,
 		}, nil
 	}
 
@@ -144,14 +268,39 @@ func calcPlus(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 		}, nil
 	}
 
+	if left.Type == ddl.ExpStr && right.Type == ddl.ExpStr {
+		return &ddl.Exp{
+			Type: ddl.ExpStr,
+			Str:  left.Str + right.Str,
+		}, nil
+	}
+
+	if left.Type == ddl.ExpStr && (right.Type == ddl.ExpInt || right.Type == ddl.ExpFloat) {
+		return &ddl.Exp{
+			Type: ddl.ExpStr,
+			Str:  left.Str + numToStr(right),
+		}, nil
+	}
+
+	if (left.Type == ddl.ExpInt || left.Type == ddl.ExpFloat) && right.Type == ddl.ExpStr {
+		return &ddl.Exp{
+			Type: ddl.ExpStr,
+			Str:  numToStr(left) + right.Str,
+		}, nil
+	}
+
 	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "+", ddl.ExpTypeName[left.Type], ddl.ExpTypeName[right.Type])
 }
 
 func calcMinus(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 	if left.Type == ddl.ExpInt && right.Type == ddl.ExpInt {
+		diff, overflow := subInt(left.Int, right.Int)
+		if overflow {
+			return nil, tperr.NewTypedError("calc.integerOverflow", left.Int, "-", right.Int)
+		}
 		return &ddl.Exp{
 			Type: ddl.ExpInt,
-			Int:  left.Int - right.Int,
+			Int:  diff,
 		}, nil
 	}
 
@@ -181,9 +330,13 @@ func calcMinus(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 
 func calcTimes(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 	if left.Type == ddl.ExpInt && right.Type == ddl.ExpInt {
+		product, overflow := mulInt(left.Int, right.Int)
+		if overflow {
+			return nil, tperr.NewTypedError("calc.integerOverflow", left.Int, "*", right.Int)
+		}
 		return &ddl.Exp{
 			Type: ddl.ExpInt,
-			Int:  left.Int * right.Int,
+			Int:  product,
 		}, nil
 	}
 
@@ -213,6 +366,13 @@ func calcTimes(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 
 func calcDivision(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 	if left.Type == ddl.ExpInt && right.Type == ddl.ExpInt {
+		// like go, the quotient of integers is truncated towards zero
+		if right.Int == 0 {
+			return nil, tperr.NewTypedError("calc.divisionByZero")
+		}
+		if left.Int == math.MinInt64 && right.Int == -1 {
+			return nil, tperr.NewTypedError("calc.integerOverflow", left.Int, "/", right.Int)
+		}
 		return &ddl.Exp{
 			Type: ddl.ExpInt,
 			Int:  left.Int / right.Int,
@@ -243,26 +403,75 @@ func calcDivision(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "/", ddl.ExpTypeName[left.Type], ddl.ExpTypeName[right.Type])
 }
 
-func calcLogicalAnd(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
-	if left.Type == ddl.ExpBool && right.Type == ddl.ExpBool {
+// like go, the remainder has the sign of the dividend, and only integers are allowed
+func calcModulo(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
+	if left.Type == ddl.ExpInt && right.Type == ddl.ExpInt {
+		if right.Int == 0 {
+			return nil, tperr.NewTypedError("calc.divisionByZero")
+		}
+		if right.Int == -1 {
+			return &ddl.Exp{
+				Type: ddl.ExpInt,
+				Int:  0,
+			}, nil
+		}
 		return &ddl.Exp{
-			Type: ddl.ExpBool,
-			Bool: left.Bool && right.Bool,
+			Type: ddl.ExpInt,
+			Int:  left.Int % right.Int,
 		}, nil
 	}
 
-	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "&&", ddl.ExpTypeName[left.Type], ddl.ExpTypeName[right.Type])
+	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "%", ddl.ExpTypeName[left.Type], ddl.ExpTypeName[right.Type])
 }
 
-func calcLogicalOr(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
-	if left.Type == ddl.ExpBool && right.Type == ddl.ExpBool {
+func calcNegative(right *ddl.Exp) (*ddl.Exp, error) {
+	if right.Type == ddl.ExpInt {
+		if right.Int == math.MinInt64 {
+			return nil, tperr.NewTypedError("calc.integerOverflow", 0, "-", right.Int)
+		}
 		return &ddl.Exp{
-			Type: ddl.ExpBool,
-			Bool: left.Bool || right.Bool,
+			Type: ddl.ExpInt,
+			Int:  -right.Int,
+		}, nil
+	}
+
+	if right.Type == ddl.ExpFloat {
+		return &ddl.Exp{
+			Type:  ddl.ExpFloat,
+			Float: -right.Float,
 		}, nil
 	}
 
-	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "||", ddl.ExpTypeName[left.Type], ddl.ExpTypeName[right.Type])
+	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "-", ddl.ExpTypeName[ddl.ExpNil], ddl.ExpTypeName[right.Type])
+}
+
+// && and ||, which skip the right operand if the left one decides the result already
+func calcLogical(exp *ddl.Exp, leftExp CompiledExp, rightExp CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	left, err := leftExp(varGetter)
+	if err != nil {
+		return nil, err
+	}
+	if left.Type != ddl.ExpBool {
+		// the right operand is evaluated only for its type
+		right, err := rightExp(varGetter)
+		if err != nil {
+			return nil, err
+		}
+		return nil, tperr.NewTypedError("calc.operandTypeMismatch", exp.Operator, left.ActualTypeName(), right.ActualTypeName())
+	}
+	if (exp.Operator == "&&" && !left.Bool) || (exp.Operator == "||" && left.Bool) {
+		return left, nil
+	}
+
+	right, err := rightExp(varGetter)
+	if err != nil {
+		return nil, err
+	}
+	if right.Type != ddl.ExpBool {
+		return nil, tperr.NewTypedError("calc.operandTypeMismatch", exp.Operator, left.ActualTypeName(), right.ActualTypeName())
+	}
+
+	return right, nil
 }
 
 func calcLogicalNot(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
@@ -574,20 +783,21 @@ func calcLessOrEqual(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 	return nil, tperr.NewTypedError("calc.operandTypeMismatch", "<=", ddl.ExpTypeName[left.Type], ddl.ExpTypeName[right.Type])
 }
 
-func calcTernaryCondition(condition *ddl.Exp, left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
-	if condition == nil || condition.Type != ddl.ExpBool {
-		return nil, tperr.NewTypedError("calc.invalidTernaryCondition", ddl.ExpTypeName[condition.Type])
+// condition ? a : b, where only the chosen branch is evaluated, and the branches may be of different types
+func calcTernary(exp *ddl.Exp, conditionExp CompiledExp, leftExp CompiledExp, rightExp CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	condition, err := conditionExp(varGetter)
+	if err != nil {
+		return nil, err
 	}
-
-	if left.Type != right.Type {
-		return nil, tperr.NewTypedError("calc.ternaryDataNotSameType")
+	if condition.Type != ddl.ExpBool {
+		return nil, ddl.NewDdlError("", exp.TenaryCondition.Pos, "calc.invalidTernaryCondition", condition.ActualTypeName())
 	}
 
 	if condition.Bool {
-		return left, nil
-	} else {
-		return right, nil
+		return leftExp(varGetter)
 	}
+
+	return rightExp(varGetter)
 }
 
 func calcVar(exp *ddl.Exp, varGetter VarGetter) (*ddl.Exp, error) {
@@ -610,14 +820,23 @@ func calcVar(exp *ddl.Exp, varGetter VarGetter) (*ddl.Exp, error) {
 		}, nil
 	}
 
-	if reflect.ValueOf(val).Kind() == reflect.Pointer {
-		val = reflect.ValueOf(val).Elem().Interface()
+	// a pointer to a struct is kept, so that the methods with a pointer receiver can be called on it
+	valVal := reflect.ValueOf(val)
+	if valVal.Kind() == reflect.Pointer {
+		if valVal.IsNil() {
+			return &ddl.Exp{
+				Type: ddl.ExpNil,
+			}, nil
+		}
+		if valVal.Elem().Kind() != reflect.Struct {
+			val = valVal.Elem().Interface()
+		}
 	}
 
 	return ConvertVariableToExp(val)
 }
 
-func calcFunc(exp *ddl.Exp, varGetter VarGetter) (*ddl.Exp, error) {
+func calcFunc(exp *ddl.Exp, params []CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
 	if exp.Type != ddl.ExpFunc {
 		return nil, tperr.NewTypedError("calc.expMustBeFuncType", `calcFunc.exp`)
 	}
@@ -631,46 +850,229 @@ func calcFunc(exp *ddl.Exp, varGetter VarGetter) (*ddl.Exp, error) {
 		return nil, err
 	}
 
-	if reflect.TypeOf(funcVar).Kind() != reflect.Func {
-		return nil, tperr.NewTypedError("calc.varIsNotFunc", exp.FuncName)
+	if funcVar == nil || reflect.TypeOf(funcVar).Kind() != reflect.Func {
+		return nil, tperr.NewTypedError("calc.variableIsNotFunc", exp.FuncName)
+	}
+
+	return callFunc(exp.FuncName, reflect.ValueOf(funcVar), params, varGetter)
+}
+
+// a ?? b, where b is evaluated only if a is nil
+func calcCoalesce(leftExp CompiledExp, rightExp CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	left, err := leftExp(varGetter)
+	if err != nil {
+		return nil, err
 	}
-	theFunc := reflect.ValueOf(funcVar)
-	theFuncType := reflect.TypeOf(funcVar)
+	if left.Type != ddl.ExpNil {
+		return left, nil
+	}
+
+	return rightExp(varGetter)
+}
+
+// a member access compiled into a closure, which also tells whether the rest of the chain is skipped
+type compiledMember func(varGetter VarGetter) (*ddl.Exp, bool, error)
 
-	if !theFuncType.IsVariadic() && len(exp.FuncParams) != theFuncType.NumIn() {
-		return nil, tperr.NewTypedError("calc.argumentNumberMismatch", exp.FuncName, theFuncType.NumIn(), len(exp.FuncParams))
+func compileMember(exp *ddl.Exp) compiledMember {
+	left := compiledMember(nil)
+	if exp.Left != nil && exp.Left.Type == ddl.ExpCalc && isMemberAccess(exp.Left.Operator) {
+		left = compileMember(exp.Left)
+	} else {
+		run := CompileExp(exp.Left)
+		left = func(varGetter VarGetter) (*ddl.Exp, bool, error) {
+			res, err := run(varGetter)
+			return res, false, err
+		}
 	}
 
-	if theFuncType.IsVariadic() && len(exp.FuncParams) != theFuncType.NumIn() {
-		return nil, tperr.NewTypedError("calc.argumentNumberNotEnough", exp.FuncName, theFuncType.NumIn()-1, len(exp.FuncParams))
+	// a method call, like a.b(), only evaluates its arguments
+	right := CompiledExp(nil)
+	params := []CompiledExp(nil)
+	if exp.Right != nil && exp.Right.Type == ddl.ExpFunc && (exp.Operator == "." || exp.Operator == "?.") {
+		params = compileExps(exp.Right.FuncParams)
+	} else {
+		right = CompileExp(exp.Right)
 	}
 
-	theParams := []reflect.Value{}
-	for idx, paramExp := range exp.FuncParams {
-		res, rerr := CalcExp(paramExp, varGetter)
+	return func(varGetter VarGetter) (*ddl.Exp, bool, error) {
+		return calcMember(exp, left, right, params, varGetter)
+	}
+}
+
+func isMemberAccess(optr string) bool {
+	return optr == "." || optr == "?." || optr == "[" || optr == "?.["
+}
+
+// a.b, a[b], a.b() and their nil-safe forms a?.b, a?.[b], a?.b().
+// once a nil-safe access meets a nil, the rest of the chain is skipped and evaluated to nil, which is told by the second result.
+func calcMember(exp *ddl.Exp, leftExp compiledMember, rightExp CompiledExp, params []CompiledExp, varGetter VarGetter) (*ddl.Exp, bool, error) {
+	left, skipped, err := leftExp(varGetter)
+	if err != nil {
+		return nil, false, err
+	}
+
+	nilSafe := exp.Operator == "?." || exp.Operator == "?.["
+	if skipped || (nilSafe && left.Type == ddl.ExpNil) {
+		return &ddl.Exp{
+			Type: ddl.ExpNil,
+		}, true, nil
+	}
+
+	res := (*ddl.Exp)(nil)
+	if exp.Right != nil && exp.Right.Type == ddl.ExpFunc && (exp.Operator == "." || exp.Operator == "?.") {
+		res, err = calcMethod(left, exp.Right, params, varGetter)
+	} else {
+		right, rerr := rightExp(varGetter)
 		if rerr != nil {
-			return nil, rerr
+			return nil, false, rerr
+		}
+
+		if exp.Operator == "." || exp.Operator == "?." {
+			res, err = calcDot(left, right)
+		} else {
+			res, err = calcSquareBracket(left, right)
+		}
+	}
+
+	if err != nil {
+		if _, ok := err.(*ddl.DdlError); !ok {
+			err = ddl.WrapDdlError("", exp.Pos, err)
+		}
+		return nil, false, err
+	}
+
+	return res, false, nil
+}
+
+// obj.Method(a, b), where obj is a go value
+func calcMethod(obj *ddl.Exp, fexp *ddl.Exp, params []CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	if obj.Type != ddl.ExpInterface {
+		return nil, ddl.NewDdlError("", fexp.Pos, "calc.methodNotExist", ddl.ExpTypeName[obj.Type], fexp.FuncName)
+	}
+
+	method := MethodByName(obj.Interface, fexp.FuncName)
+	if !method.IsValid() {
+		return nil, ddl.NewDdlError("", fexp.Pos, "calc.methodNotExist", reflect.TypeOf(obj.Interface).String(), fexp.FuncName)
+	}
+
+	res, err := callFunc(fexp.FuncName, method, params, varGetter)
+	if err != nil {
+		if _, ok := err.(*ddl.DdlError); !ok {
+			err = ddl.WrapDdlError("", fexp.Pos, err)
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// value | filter, or value | filter(a, b), where the value is passed to the filter as the first argument
+func calcFilter(exp *ddl.Exp, valExp CompiledExp, params []CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	fexp := exp.Right
+	name := fexp.Variable
+	if fexp.Type == ddl.ExpFunc {
+		name = fexp.FuncName
+	}
+
+	val, err := valExp(varGetter)
+	if err != nil {
+		return nil, err
+	}
+
+	// the page's own filters come first, then the global ones, and then the helpers, like upper.
+	// a getter which knows nothing of filters, like the one of a struct, fails to get one, which is the same as having none.
+	filter, err := varGetter(FilterVarPrefix + name)
+	if err != nil {
+		filter = nil
+	}
+	if filter == nil {
+		filter = getGlobalFilter(name)
+	}
+	if filter == nil {
+		filter = getHelper(name)
+	}
+	if filter == nil {
+		return nil, ddl.NewDdlError("", fexp.Pos, "calc.filterNotExist", name)
+	}
+
+	args := []*ddl.Exp{val}
+	for _, param := range params {
+		arg, aerr := param(varGetter)
+		if aerr != nil {
+			return nil, aerr
+		}
+		args = append(args, arg)
+	}
+
+	res, err := invokeFunc(name, reflect.ValueOf(filter), args)
+	if err != nil {
+		if _, ok := err.(*ddl.DdlError); !ok {
+			err = ddl.WrapDdlError("", fexp.Pos, err)
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// find a method of a value by its name.
+// the methods with a pointer receiver are also found for a non-pointer value, by calling them on a copy of it.
+func MethodByName(obj interface{}, name string) reflect.Value {
+	if obj == nil {
+		return reflect.Value{}
+	}
+
+	val := reflect.ValueOf(obj)
+	method := val.MethodByName(name)
+	if method.IsValid() || val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		return method
+	}
+
+	ptr := reflect.New(val.Type())
+	ptr.Elem().Set(val)
+	return ptr.MethodByName(name)
+}
+
+// call a function with the compiled arguments of a function expression
+func callFunc(name string, theFunc reflect.Value, params []CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	args := make([]*ddl.Exp, 0, len(params))
+	for _, param := range params {
+		arg, err := param(varGetter)
+		if err != nil {
+			return nil, err
 		}
+		args = append(args, arg)
+	}
 
-		param := reflect.Value{}
-		switch res.Type {
-		case ddl.ExpBool:
-			param = reflect.ValueOf(res.Bool)
+	return invokeFunc(name, theFunc, args)
+}
 
-		case ddl.ExpStr:
-			param = reflect.ValueOf(res.Str)
+// call a function with the evaluated arguments.
+// if the last result of the function is an error and it is not nil, the error is returned.
+func invokeFunc(name string, theFunc reflect.Value, args []*ddl.Exp) (*ddl.Exp, error) {
+	theFuncType := theFunc.Type()
+	numIn := theFuncType.NumIn()
 
-		case ddl.ExpInt:
-			param = reflect.ValueOf(res.Int).Convert(theFuncType.In(idx))
+	if !theFuncType.IsVariadic() && len(args) != numIn {
+		return nil, tperr.NewTypedError("calc.argumentNumberMismatch", name, numIn, len(args))
+	}
 
-		case ddl.ExpFloat:
-			param = reflect.ValueOf(res.Float).Convert(theFuncType.In(idx))
+	if theFuncType.IsVariadic() && len(args) < numIn-1 {
+		return nil, tperr.NewTypedError("calc.argumentNumberNotEnough", name, numIn-1, len(args))
+	}
 
-		case ddl.ExpNil:
-			param = reflect.ValueOf(nil)
+	theParams := []reflect.Value{}
+	for idx, res := range args {
+		paramType := reflect.Type(nil)
+		if theFuncType.IsVariadic() && idx >= numIn-1 {
+			paramType = theFuncType.In(numIn - 1).Elem()
+		} else {
+			paramType = theFuncType.In(idx)
+		}
 
-		case ddl.ExpInterface:
-			param = reflect.ValueOf(exp.Interface)
+		param, ok := expToTypedValue(res, paramType)
+		if !ok {
+			return nil, tperr.NewTypedError("calc.argumentTypeMismatch", name, idx+1, paramType.String(), res.ActualTypeName())
 		}
 		theParams = append(theParams, param)
 	}
@@ -682,16 +1084,250 @@ func calcFunc(exp *ddl.Exp, varGetter VarGetter) (*ddl.Exp, error) {
 		}, nil
 	}
 
-	val := res[0].Interface()
+	last := res[len(res)-1]
+	if theFuncType.Out(len(res)-1) == errorType {
+		if !last.IsNil() {
+			cause := last.Interface().(error)
+			return nil, tperr.NewTypedError("calc.funcReturnedError", name, cause.Error()).Wrap(cause)
+		}
+		if len(res) == 1 {
+			return &ddl.Exp{
+				Type: ddl.ExpNil,
+			}, nil
+		}
+	}
+
+	return ConvertVariableToExp(res[0].Interface())
+}
 
-	return ConvertVariableToExp(val)
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func isNumberKind(kind reflect.Kind) bool {
+	return (kind >= reflect.Int && kind <= reflect.Uint64) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// the go value of an evaluated expression as a value of a type, or false if it can't be one.
+// a nil is the zero value of any type, a number can be converted to any number type which holds it exactly,
+// and the other values only to a type of the same kind, such as a named string type.
+func expToTypedValue(exp *ddl.Exp, typ reflect.Type) (reflect.Value, bool) {
+	val := reflect.Value{}
+	switch exp.Type {
+	case ddl.ExpBool:
+		val = reflect.ValueOf(exp.Bool)
+
+	case ddl.ExpStr:
+		val = reflect.ValueOf(exp.Str)
+
+	case ddl.ExpInt:
+		// an int, rather than an int64, is kept in an interface{}
+		if typ.Kind() == reflect.Interface {
+			val = reflect.ValueOf(int(exp.Int))
+		} else {
+			val = reflect.ValueOf(exp.Int)
+		}
+
+	case ddl.ExpFloat:
+		val = reflect.ValueOf(exp.Float)
+
+	case ddl.ExpNil:
+		return reflect.Zero(typ), true
+
+	case ddl.ExpInterface:
+		val = reflect.ValueOf(exp.Interface)
+	}
+
+	if !canPassAs(val, typ) {
+		return reflect.Value{}, false
+	}
+
+	return val.Convert(typ), true
+}
+
+func canPassAs(param reflect.Value, paramType reflect.Type) bool {
+	if !param.IsValid() {
+		return false
+	}
+
+	if param.Type().AssignableTo(paramType) {
+		return true
+	}
+
+	if isNumberKind(param.Kind()) && isNumberKind(paramType.Kind()) {
+		return numberFits(param, paramType)
+	}
+
+	return param.Kind() == paramType.Kind() && param.Type().ConvertibleTo(paramType)
+}
+
+// tell whether a number keeps its value as a number type, so that 300 isn't passed as an uint8, nor 1.9 as an int
+func numberFits(num reflect.Value, numType reflect.Type) bool {
+	zero := reflect.Zero(numType)
+	dstKind := numType.Kind()
+	dstInt := dstKind >= reflect.Int && dstKind <= reflect.Int64
+	dstUint := dstKind >= reflect.Uint && dstKind <= reflect.Uint64
+
+	switch kind := num.Kind(); {
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		val := num.Int()
+		switch {
+		case dstInt:
+			return !zero.OverflowInt(val)
+		case dstUint:
+			return val >= 0 && !zero.OverflowUint(uint64(val))
+		}
+		return true
+
+	case kind >= reflect.Uint && kind <= reflect.Uintptr:
+		val := num.Uint()
+		switch {
+		case dstInt:
+			return val <= math.MaxInt64 && !zero.OverflowInt(int64(val))
+		case dstUint:
+			return !zero.OverflowUint(val)
+		}
+		return true
+
+	default:
+		val := num.Float()
+		switch {
+		case dstInt:
+			return val == math.Trunc(val) && val >= math.MinInt64 && val < math.MaxInt64 && !zero.OverflowInt(int64(val))
+		case dstUint:
+			return val == math.Trunc(val) && val >= 0 && val < math.MaxUint64 && !zero.OverflowUint(uint64(val))
+		}
+		return !zero.OverflowFloat(val)
+	}
+}
+
+// [a, b, ...] is evaluated into a slice.
+// the slice is typed if all the elements are of the same type, like []int or []string, otherwise it is an []interface{}
+func calcArray(itemExps []CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	items := make([]*ddl.Exp, 0, len(itemExps))
+	for _, itemExp := range itemExps {
+		item, err := itemExp(varGetter)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	elemType := literalElemType(items)
+	arr := reflect.MakeSlice(reflect.SliceOf(elemType), 0, len(items))
+	for _, item := range items {
+		arr = reflect.Append(arr, literalElemValue(item, elemType))
+	}
+
+	return &ddl.Exp{
+		Type:      ddl.ExpInterface,
+		Interface: arr.Interface(),
+	}, nil
+}
+
+// {key: val, ...} is evaluated into a map with string keys, whose values are typed like the elements of an array literal
+func calcMap(keys []string, valExps []CompiledExp, varGetter VarGetter) (*ddl.Exp, error) {
+	items := make([]*ddl.Exp, 0, len(valExps))
+	for _, valExp := range valExps {
+		val, err := valExp(varGetter)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, val)
+	}
+
+	elemType := literalElemType(items)
+	theMap := reflect.MakeMapWithSize(reflect.MapOf(reflect.TypeOf(""), elemType), len(items))
+	for idx, item := range items {
+		theMap.SetMapIndex(reflect.ValueOf(keys[idx]), literalElemValue(item, elemType))
+	}
+
+	return &ddl.Exp{
+		Type:      ddl.ExpInterface,
+		Interface: theMap.Interface(),
+	}, nil
+}
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// the go value of an evaluated expression
+func expToValue(exp *ddl.Exp) interface{} {
+	switch exp.Type {
+	case ddl.ExpInt:
+		return int(exp.Int)
+
+	case ddl.ExpFloat:
+		return exp.Float
+
+	case ddl.ExpStr:
+		return exp.Str
+
+	case ddl.ExpBool:
+		return exp.Bool
+
+	case ddl.ExpInterface:
+		return exp.Interface
+	}
+
+	return nil
+}
+
+// the common type of the elements of a literal.
+// a mix of ints and floats makes float64, and any other mix makes interface{}.
+func literalElemType(items []*ddl.Exp) reflect.Type {
+	if len(items) == 0 {
+		return interfaceType
+	}
+
+	isNumber := true
+	hasFloat := false
+	for _, item := range items {
+		isNumber = isNumber && (item.Type == ddl.ExpInt || item.Type == ddl.ExpFloat)
+		hasFloat = hasFloat || item.Type == ddl.ExpFloat
+	}
+	if isNumber && hasFloat {
+		return reflect.TypeOf(float64(0))
+	}
+
+	elemType := reflect.TypeOf(expToValue(items[0]))
+	for _, item := range items[1:] {
+		if reflect.TypeOf(expToValue(item)) != elemType {
+			return interfaceType
+		}
+	}
+	if elemType == nil {
+		return interfaceType
+	}
+
+	return elemType
+}
+
+func literalElemValue(item *ddl.Exp, elemType reflect.Type) reflect.Value {
+	val := expToValue(item)
+	if val == nil {
+		return reflect.Zero(elemType)
+	}
+
+	return reflect.ValueOf(val).Convert(elemType)
+}
+
+// a part of a ReactiveSlice, a ReactiveMap or a ReactiveStruct, which is watched on its own
+func calcReactivePart(container reactiveContainer, key *ddl.Exp) (*ddl.Exp, error) {
+	res, err := container.readPart(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return ConvertVariableToExp(res)
 }
 
 func calcDot(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
+	if container, ok := left.Interface.(reactiveContainer); ok && left.Type == ddl.ExpInterface {
+		return calcReactivePart(container, right)
+	}
+
 	if left.Type == ddl.ExpInterface && right.Type == ddl.ExpStr {
 		leftVal := reflect.ValueOf(left.Interface)
 
-		if leftVal.Kind() == reflect.Struct && right.Type == ddl.ExpStr {
+		if isStructOrStructPointer(leftVal) && right.Type == ddl.ExpStr {
 			res, err := GetStructField(left.Interface, right.Str)
 			if err != nil {
 				return nil, err
@@ -707,32 +1343,27 @@ func calcDot(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 				mapKeyKind == reflect.Uint64 || mapKeyKind == reflect.Int8 || mapKeyKind == reflect.Int16 ||
 				mapKeyKind == reflect.Int32 || mapKeyKind == reflect.Int64) && right.Type == ddl.ExpInt {
 				key := reflect.ValueOf(right.Int).Convert(mapKeyType)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if (mapKeyKind == reflect.Float32 || mapKeyKind == reflect.Float64) && right.Type == ddl.ExpFloat {
 				key := reflect.ValueOf(right.Float).Convert(mapKeyType)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if mapKeyKind == reflect.String && right.Type == ddl.ExpStr {
 				key := reflect.ValueOf(right.Str)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if mapKeyKind == reflect.Bool && right.Type == ddl.ExpBool {
 				key := reflect.ValueOf(right.Bool)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if right.Type == ddl.ExpInterface && reflect.ValueOf(right.Interface).CanConvert(mapKeyType) {
 				key := reflect.ValueOf(right.Interface).Convert(mapKeyType)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 		}
 
@@ -749,10 +1380,14 @@ func calcDot(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 }
 
 func calcSquareBracket(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
+	if container, ok := left.Interface.(reactiveContainer); ok && left.Type == ddl.ExpInterface {
+		return calcReactivePart(container, right)
+	}
+
 	if left.Type == ddl.ExpInterface {
 		leftVal := reflect.ValueOf(left.Interface)
 
-		if leftVal.Kind() == reflect.Struct && right.Type == ddl.ExpStr {
+		if isStructOrStructPointer(leftVal) && right.Type == ddl.ExpStr {
 			res, err := GetStructField(left.Interface, right.Str)
 			if err != nil {
 				return nil, err
@@ -768,38 +1403,36 @@ func calcSquareBracket(left *ddl.Exp, right *ddl.Exp) (*ddl.Exp, error) {
 				mapKeyKind == reflect.Uint64 || mapKeyKind == reflect.Int8 || mapKeyKind == reflect.Int16 ||
 				mapKeyKind == reflect.Int32 || mapKeyKind == reflect.Int64) && right.Type == ddl.ExpInt {
 				key := reflect.ValueOf(right.Int).Convert(mapKeyType)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if (mapKeyKind == reflect.Float32 || mapKeyKind == reflect.Float64) && right.Type == ddl.ExpFloat {
 				key := reflect.ValueOf(right.Float).Convert(mapKeyType)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if mapKeyKind == reflect.String && right.Type == ddl.ExpStr {
 				key := reflect.ValueOf(right.Str)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if mapKeyKind == reflect.Bool && right.Type == ddl.ExpBool {
 				key := reflect.ValueOf(right.Bool)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 
 			if right.Type == ddl.ExpInterface && reflect.ValueOf(right.Interface).CanConvert(mapKeyType) {
 				key := reflect.ValueOf(right.Interface).Convert(mapKeyType)
-				res := leftVal.MapIndex(key).Interface()
-				return ConvertVariableToExp(res)
+				return mapIndex(leftVal, key)
 			}
 		}
 
 		if leftVal.Kind() == reflect.Array || leftVal.Kind() == reflect.Slice {
 			if right.Type == ddl.ExpInt {
 				idx := right.Int
+				if idx < 0 || idx >= int64(leftVal.Len()) {
+					return nil, tperr.NewTypedError("calc.indexOutOfRange", idx, leftVal.Len())
+				}
 				res := leftVal.Index(int(idx)).Interface()
 				return ConvertVariableToExp(res)
 			}
@@ -910,9 +1543,30 @@ func ConvertVariableToExp(variable interface{}) (*ddl.Exp, error) {
 		}, nil
 
 	default:
+		if val := reflect.ValueOf(v); val.Kind() == reflect.Pointer && val.IsNil() {
+			return &ddl.Exp{
+				Type: ddl.ExpNil,
+			}, nil
+		}
 		return &ddl.Exp{
 			Type:      ddl.ExpInterface,
 			Interface: v,
 		}, nil
 	}
 }
+
+// the value of a key in a map, or nil if the key doesn't exist
+func mapIndex(theMap reflect.Value, key reflect.Value) (*ddl.Exp, error) {
+	val := theMap.MapIndex(key)
+	if !val.IsValid() {
+		return &ddl.Exp{
+			Type: ddl.ExpNil,
+		}, nil
+	}
+
+	return ConvertVariableToExp(val.Interface())
+}
+
+func isStructOrStructPointer(val reflect.Value) bool {
+	return val.Kind() == reflect.Struct || (val.Kind() == reflect.Pointer && val.Elem().Kind() == reflect.Struct)
+}
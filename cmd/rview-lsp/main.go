@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TinyWisp/rview"
+	"github.com/TinyWisp/rview/lsp"
+)
+
+// a language server for rview templates, talking to the editor over stdio
+func main() {
+	server := lsp.NewServer(rview.DefaultTagCompCreatorMap)
+	if err := server.Run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
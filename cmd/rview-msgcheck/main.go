@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/TinyWisp/rview/tperr"
+)
+
+// list the message keys that are missing from each catalog of tperr.
+// it exits with 1 if any catalog is incomplete.
+func main() {
+	locale := flag.String("locale", "", "only check the catalog of this locale")
+	flag.Parse()
+
+	locales := tperr.Locales()
+	if *locale != "" {
+		locales = []string{*locale}
+	}
+
+	incomplete := false
+	for _, loc := range locales {
+		if loc == tperr.DefaultLocale {
+			continue
+		}
+
+		missing := tperr.MissingKeys(loc)
+		if len(missing) == 0 {
+			fmt.Printf("%s: complete\n", loc)
+			continue
+		}
+
+		incomplete = true
+		fmt.Printf("%s: %d missing\n", loc, len(missing))
+		for _, key := range missing {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+
+	if incomplete {
+		os.Exit(1)
+	}
+}
@@ -3,15 +3,54 @@ package comp
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/TinyWisp/rview/tperr"
+	"github.com/gdamore/tcell/v2"
 	"github.com/iancoleman/strcase"
+	"github.com/rivo/tview"
 )
 
 type Base[T any] struct {
 	name      string
 	outerInst interface{}
 	tviewInst T
+	hidden    bool
+	primitive *shownPrimitive
+}
+
+// the primitive of a component, which draws nothing and takes no input while the component is hidden
+type shownPrimitive struct {
+	tview.Primitive
+	hidden *bool
+}
+
+func (sp *shownPrimitive) Draw(screen tcell.Screen) {
+	if *sp.hidden {
+		return
+	}
+	sp.Primitive.Draw(screen)
+}
+
+func (sp *shownPrimitive) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	if *sp.hidden {
+		return nil
+	}
+	return sp.Primitive.InputHandler()
+}
+
+func (sp *shownPrimitive) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	if *sp.hidden {
+		return nil
+	}
+	return sp.Primitive.MouseHandler()
+}
+
+func (sp *shownPrimitive) PasteHandler() func(text string, setFocus func(p tview.Primitive)) {
+	if *sp.hidden {
+		return nil
+	}
+	return sp.Primitive.PasteHandler()
 }
 
 func (b *Base[T]) GetName() string {
@@ -68,6 +107,96 @@ func (b *Base[T]) GetProp(prop string) (interface{}, error) {
 	return res[0].Interface(), nil
 }
 
+// the events whose setters are not named after them, like @click, which is set by SetSelectedFunc of a tview.Button
+var eventAliases = map[string]string{
+	"click":  "selected",
+	"change": "changed",
+}
+
+// bind a handler to an event through a Set<Event>Func method, like SetChangedFunc for @changed.
+// the handler gets the arguments of the callback, and the results of the callback, if any, are zero values.
+func (b *Base[T]) SetEventHandler(event string, handler func(args ...interface{})) error {
+	name := event
+	if alias, ok := eventAliases[event]; ok {
+		name = alias
+	}
+	funcName := fmt.Sprintf("Set%sFunc", strcase.ToCamel(name))
+
+	setter := reflect.ValueOf(b.outerInst).MethodByName(funcName)
+	if !setter.IsValid() {
+		setter = reflect.ValueOf(b.tviewInst).MethodByName(funcName)
+	}
+	if !setter.IsValid() || setter.Type().NumIn() != 1 || setter.Type().In(0).Kind() != reflect.Func {
+		return tperr.NewTypedError("comp.SetEventHandler.eventNotSupported", event, b.GetName())
+	}
+
+	callbackType := setter.Type().In(0)
+	callback := reflect.MakeFunc(callbackType, func(in []reflect.Value) []reflect.Value {
+		args := make([]interface{}, 0, len(in))
+		for _, arg := range in {
+			args = append(args, arg.Interface())
+		}
+		handler(args...)
+
+		out := make([]reflect.Value, 0, callbackType.NumOut())
+		for idx := 0; idx < callbackType.NumOut(); idx++ {
+			out = append(out, reflect.Zero(callbackType.Out(idx)))
+		}
+		return out
+	})
+	setter.Call([]reflect.Value{callback})
+
+	return nil
+}
+
+// get the props that can be set through SetProp, along with their types
+func (b *Base[T]) GetPropTypes() map[string]reflect.Type {
+	propTypes := map[string]reflect.Type{}
+
+	for _, inst := range []interface{}{b.tviewInst, b.outerInst} {
+		instType := reflect.TypeOf(inst)
+		if instType == nil {
+			continue
+		}
+		for idx := 0; idx < instType.NumMethod(); idx++ {
+			method := instType.Method(idx)
+			if !strings.HasPrefix(method.Name, "Set") || method.Name == "SetProp" {
+				continue
+			}
+			// the receiver is the first input
+			if method.Type.NumIn() != 2 {
+				continue
+			}
+			propTypes[strcase.ToLowerCamel(method.Name[3:])] = method.Type.In(1)
+		}
+	}
+
+	return propTypes
+}
+
+// the tview primitive to put the component on the screen, which draws nothing while the component is hidden
+func (b *Base[T]) Primitive() tview.Primitive {
+	if b.primitive == nil {
+		inner, ok := interface{}(b.tviewInst).(tview.Primitive)
+		if !ok {
+			return nil
+		}
+		b.primitive = &shownPrimitive{Primitive: inner, hidden: &b.hidden}
+	}
+
+	return b.primitive
+}
+
+// hide or show the component without destroying it, which is what v-show does.
+// a hidden component keeps its state, while its primitive draws nothing and takes no input.
+func (b *Base[T]) SetVisible(visible bool) {
+	b.hidden = !visible
+}
+
+func (b *Base[T]) GetVisible() bool {
+	return !b.hidden
+}
+
 func (b *Base[T]) CanAddItem() bool {
 	return false
 }
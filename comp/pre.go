@@ -0,0 +1,26 @@
+package comp
+
+import (
+	"github.com/rivo/tview"
+)
+
+// preformatted text, shown as it is in the template
+type Pre struct {
+	Base[*tview.TextView]
+}
+
+func (p *Pre) GetText() string {
+	return p.tviewInst.GetText(false)
+}
+
+func CreatePre() Component {
+	pre := &Pre{
+		Base: Base[*tview.TextView]{
+			name:      "pre",
+			tviewInst: tview.NewTextView().SetWrap(false),
+		},
+	}
+	pre.Base.outerInst = pre
+
+	return pre
+}
@@ -0,0 +1,153 @@
+package rview
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// the state of a Computed, whose value is kept in its Ref.
+// it is computed lazily: a change of a dependency only marks it dirty, and it is computed again by the next Get,
+// so it is never computed if nobody reads it, and computed once however many of its dependencies are changed.
+// a watcher of it is triggered only if the computed value is changed.
+type computed[T any] struct {
+	mutex    sync.Mutex
+	ref      *Ref[T]
+	fn       func() T
+	watcher  *Watcher
+	dirty    atomic.Bool
+	disposed bool
+
+	// the value which the watchers of the Ref have been told about
+	notified T
+}
+
+// create a Ref whose value is computed by fnCompute from other Refs.
+// it is computed again when it is read after some of the Refs are changed, and Dispose or the EffectScope stops watching them.
+func Computed[T any](fnCompute func() T) *Ref[T] {
+	c := &computed[T]{
+		ref: NewRef[T](*new(T)),
+		fn:  fnCompute,
+	}
+	c.ref.computed = c
+	c.dirty.Store(true)
+
+	// the watcher is run by the first Get, rather than by NewWatcher, as nothing is computed until then
+	c.watcher = &Watcher{
+		id:          watcherCount.Add(1),
+		watchWhat:   c.compute,
+		mutex:       &sync.Mutex{},
+		doSomething: c.notify,
+		markDirty:   c.markDirty,
+	}
+	recordEffect(c.ref.Dispose)
+
+	return c.ref
+}
+
+// stop a Computed watching its dependencies, which keeps its last value from then on.
+// it does nothing to a Ref which is not a Computed.
+func (r *Ref[T]) Dispose() {
+	c := r.computed
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.disposed = true
+	c.watcher.clean()
+}
+
+func (c *computed[T]) compute() {
+	val := c.fn()
+
+	c.ref.mutex.Lock()
+	c.ref.oldValue = c.ref.value
+	c.ref.value = val
+	c.ref.mutex.Unlock()
+}
+
+// compute the value again if it is dirty
+func (c *computed[T]) refresh() {
+	if !c.dirty.Load() {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.disposed || !c.dirty.Load() {
+		return
+	}
+
+	// a dependency changed while computing marks it dirty again
+	c.dirty.Store(false)
+	c.watcher.RunAndWatch()
+
+	// nobody is told about the value if nobody watches it, so the next change is compared with this one
+	if len(c.effects()) == 0 {
+		c.notified = c.peek()
+	}
+}
+
+// mark it and the Computeds depending on it dirty, and queue them to tell their watchers about the change
+func (c *computed[T]) markDirty() {
+	if c.dirty.Swap(true) {
+		return
+	}
+
+	for _, watcher := range c.lazyWatchers() {
+		watcher.markDirty()
+		runWatcher(watcher, c.ref, nil, nil)
+	}
+}
+
+// compute the value again and trigger the watchers if it is changed, which runs after all the dirty Computeds are marked
+func (c *computed[T]) notify(ref Watchable, newVal interface{}, oldVal interface{}) {
+	effects := c.effects()
+	if len(effects) == 0 {
+		return
+	}
+
+	c.refresh()
+
+	c.mutex.Lock()
+	val, old := c.peek(), c.notified
+	changed := !isSameValue(old, val)
+	c.notified = val
+	c.mutex.Unlock()
+
+	if changed {
+		for _, watcher := range effects {
+			runWatcher(watcher, c.ref, val, old)
+		}
+	}
+}
+
+func (c *computed[T]) peek() T {
+	c.ref.mutex.RLock()
+	defer c.ref.mutex.RUnlock()
+	return c.ref.value
+}
+
+// the watchers of the Ref other than the Computeds, which are marked dirty instead
+func (c *computed[T]) effects() []*Watcher {
+	return c.watchers(false)
+}
+
+func (c *computed[T]) lazyWatchers() []*Watcher {
+	return c.watchers(true)
+}
+
+func (c *computed[T]) watchers(lazy bool) []*Watcher {
+	c.ref.mutex.RLock()
+	defer c.ref.mutex.RUnlock()
+
+	watchers := []*Watcher{}
+	for _, watcher := range c.ref.watchers {
+		if (watcher.markDirty != nil) == lazy {
+			watchers = append(watchers, watcher)
+		}
+	}
+
+	return watchers
+}
@@ -0,0 +1,93 @@
+package rview
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLazyComputed(t *testing.T) {
+	a, b := NewRef(1), NewRef(2)
+	computes := 0
+	sum := Computed(func() int {
+		computes += 1
+		return a.Get() + b.Get()
+	})
+
+	// nothing is computed until it is read, and it is computed once for several changes
+	if computes != 0 {
+		t.Fatalf("a Computed is expected to be computed by the first Get, got: %d computes", computes)
+	}
+	a.Set(2)
+	b.Set(3)
+	if sum.Get() != 5 || sum.Get() != 5 || computes != 1 {
+		t.Fatalf("a Computed is expected to be computed once, got: %d computes", computes)
+	}
+	a.Set(3)
+	a.Set(4)
+	if computes != 1 {
+		t.Fatalf("a Computed is expected to wait for a Get, got: %d computes", computes)
+	}
+	if sum.Get() != 7 || computes != 2 {
+		t.Fatalf("a dirty Computed is expected to be computed again, got: %d computes", computes)
+	}
+
+	// a disposed Computed keeps its last value
+	sum.Dispose()
+	a.Set(10)
+	if sum.Get() != 7 || computes != 2 || len(a.watchers) != 0 {
+		t.Fatalf("a disposed Computed is expected to stop watching, got: %d, %d computes", sum.Get(), computes)
+	}
+}
+
+func TestComputedDiamond(t *testing.T) {
+	// a -> double, triple -> total, read by a watcher
+	a := NewRef(1)
+	double := Computed(func() int { return a.Get() * 2 })
+	triple := Computed(func() int { return a.Get() * 3 })
+	totalComputes := 0
+	total := Computed(func() int {
+		totalComputes += 1
+		return double.Get() + triple.Get()
+	})
+	parity := Computed(func() bool { return a.Get()%2 == 0 })
+
+	seen := []string{}
+	stop := RunReactively(func() {
+		seen = append(seen, fmt.Sprintf("%d+%d=%d", double.Get(), triple.Get(), total.Get()))
+	})
+	defer stop()
+
+	parityRuns := 0
+	unwatch := WatchRef(parity, func(newVal bool, oldVal bool) {
+		parityRuns += 1
+	}, false)
+	defer unwatch()
+
+	// the watcher runs once, and never sees a Computed which is not updated yet
+	a.Set(2)
+	if len(seen) != 2 || seen[1] != "4+6=10" || totalComputes != 2 {
+		t.Fatalf("the watcher is expected to run once with the new values, got: %v, %d computes", seen, totalComputes)
+	}
+
+	// a watcher of a Computed is triggered only if the computed value is changed
+	a.Set(4)
+	if parityRuns != 1 {
+		t.Fatalf("the watcher of parity is expected to run once, got: %d", parityRuns)
+	}
+
+	// with a scheduler, the Computeds are dirty at once, while the watchers wait for the flush
+	flushes := []func(){}
+	SetScheduler(NewScheduler(func(flush func()) {
+		flushes = append(flushes, flush)
+	}))
+	defer SetScheduler(nil)
+
+	a.Set(6)
+	if total.Get() != 30 || len(seen) != 3 {
+		t.Fatalf("the Computed is expected to be updated before the flush, got: %d, %v", total.Get(), seen)
+	}
+	flushes[0]()
+	if len(seen) != 4 || seen[3] != "12+18=30" {
+		t.Fatalf("the watcher is expected to run once in the flush, got: %v", seen)
+	}
+}
@@ -1,19 +1,38 @@
 package ddl
 
+import (
+	"strings"
+)
+
 type DDLDef struct {
 	TplMap      map[string]*TplNode
 	CssClassMap CSSClassMap
 }
 
 func ParseDdl(ddl string) (DDLDef, error) {
+	def, errs := parseDdl(ddl, false)
+	if len(errs) > 0 {
+		return def, errs[0]
+	}
+
+	return def, nil
+}
+
+// parse a ddl without stopping at the first error.
+// all the errors found are returned, along with a best-effort definition that tools can still work on.
+func ParseDdlWithRecovery(ddl string) (DDLDef, []*DdlError) {
+	return parseDdl(ddl, true)
+}
+
+func parseDdl(ddl string, recoverable bool) (DDLDef, []*DdlError) {
 	def := DDLDef{
 		TplMap:      map[string]*TplNode{},
 		CssClassMap: CSSClassMap{},
 	}
 
-	tplNodes, err := parseTpl(ddl)
-	if err != nil {
-		return def, err
+	tplNodes, errs := parseTplWithRecovery(ddl, recoverable)
+	if len(errs) > 0 && !recoverable {
+		return def, errs
 	}
 	for _, tn := range tplNodes {
 		// template
@@ -26,15 +45,27 @@ func ParseDdl(ddl string) (DDLDef, error) {
 
 			// style
 		} else if tn.TagName == "style" {
-			if len(tn.Children) > 1 {
-				return def, NewDdlError(ddl, tn.Pos, "ddl.invalidStyleSection")
+			if len(tn.Children) > 1 || (len(tn.Children) == 1 && tn.Children[0].Type != TplNodeText) {
+				errs = append(errs, NewDdlError(ddl, tn.Pos, "ddl.invalidStyleSection"))
+				if !recoverable {
+					return def, errs
+				}
+				continue
 			}
-			if len(tn.Children) == 1 && tn.Children[0].Type != TplNodeText {
-				return def, NewDdlError(ddl, tn.Pos, "ddl.invalidStyleSection")
+			if len(tn.Children) == 0 {
+				continue
 			}
-			classMap, cerr := parseCss(tn.Children[0].Text)
-			if cerr != nil {
-				return def, cerr
+
+			textNode := tn.Children[0]
+			offset := textNode.Pos + strings.Index(ddl[textNode.Pos:], textNode.Text)
+			classMap, cerrs := parseCssWithRecovery(textNode.Text, recoverable)
+			for _, cerr := range cerrs {
+				cerr.AddOffset(offset)
+				cerr.SetDdl(ddl)
+				errs = append(errs, cerr)
+			}
+			if len(cerrs) > 0 && !recoverable {
+				return def, errs
 			}
 			for key, val := range classMap {
 				def.CssClassMap[key] = val
@@ -42,5 +73,5 @@ func ParseDdl(ddl string) (DDLDef, error) {
 		}
 	}
 
-	return def, nil
+	return def, errs
 }
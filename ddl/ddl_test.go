@@ -1,9 +1,12 @@
 package ddl
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/TinyWisp/rview/tperr"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -204,3 +207,136 @@ func TestParseDdl(t *testing.T) {
 		}
 	}
 }
+
+type parseDdlWithRecoveryTestCase struct {
+	str       string
+	errs      []string
+	errPos    []int
+	templates []string
+	classes   []string
+}
+
+var parseDdlWithRecoveryTestCases = []parseDdlWithRecoveryTestCase{
+	{
+		str:       `<template><box :title="1 +"></box><box v-if="(a"></box></template>`,
+		errs:      []string{"exp.incompleteExpression", "exp.mismatchedParenthesis"},
+		errPos:    []int{25, 45},
+		templates: []string{"main"},
+	},
+	{
+		str:       `<template><flex><box></flex></template>`,
+		errs:      []string{"tpl.mismatchedTag", "tpl.missingClosingTag"},
+		errPos:    []int{21, 16},
+		templates: []string{"main"},
+	},
+	{
+		str:       `<template><box></p></box></template></div>`,
+		errs:      []string{"tpl.mismatchedTag", "tpl.missingOpeningTag"},
+		errPos:    []int{15, 36},
+		templates: []string{"main"},
+	},
+	{
+		str:       `<template><box title="a></box></template><template def='item(a)'><box/></template>`,
+		errs:      []string{"tpl.mismatchedDoubleQuotationMark"},
+		errPos:    []int{21},
+		templates: []string{"main", "item"},
+	},
+	{
+		str:       `<template><box>{{ a + }}</box>{{ b</template>`,
+		errs:      []string{"exp.incompleteExpression", "tpl.mismatchedCurlyBrace", "tpl.missingClosingTag"},
+		errPos:    []int{20, 30, 0},
+		templates: []string{"main"},
+	},
+	{
+		str:       "<template><box/></template>\n<style>\n.a { margin: 1ch; }\n.b { colour: red; }\n.c { padding: 2ch; }\n.d { margin: 'x'; }\n</style>",
+		errs:      []string{"css.unsupportedProp", "css.invalidPropVal"},
+		errPos:    []int{61, 110},
+		templates: []string{"main"},
+		classes:   []string{"a", "c"},
+	},
+	{
+		str:     "<style>\n/* a < b {{ } */\n.a { margin: 1ch; }\n.b { foo: 1ch; }\n</style>",
+		errs:    []string{"css.unsupportedProp"},
+		errPos:  []int{50},
+		classes: []string{"a"},
+	},
+}
+
+func TestParseDdlWithRecovery(t *testing.T) {
+	for _, testCase := range parseDdlWithRecoveryTestCases {
+		t.Logf("ddl: %s\n", testCase.str)
+		def, errs := ParseDdlWithRecovery(testCase.str)
+		for _, err := range errs {
+			t.Log(err)
+		}
+
+		if len(errs) != len(testCase.errs) {
+			t.Fatalf("expect %d errors, got %d", len(testCase.errs), len(errs))
+		}
+		for idx, err := range errs {
+			if err.etype != testCase.errs[idx] || err.pos != testCase.errPos[idx] {
+				t.Fatalf("error #%d: expect %s at %d, got %s at %d", idx, testCase.errs[idx], testCase.errPos[idx], err.etype, err.pos)
+			}
+		}
+
+		for _, name := range testCase.templates {
+			if _, ok := def.TplMap[name]; !ok {
+				t.Fatalf("the template '%s' is expected to be recovered", name)
+			}
+		}
+		for _, class := range testCase.classes {
+			if _, ok := def.CssClassMap[class]; !ok {
+				t.Fatalf("the css class '%s' is expected to be recovered", class)
+			}
+		}
+	}
+}
+
+func TestDiagnostic(t *testing.T) {
+	str := "<template>\n  <box a='1' a='2'></box>\n</template>"
+	_, err := ParseDdl(str)
+	derr, ok := err.(*DdlError)
+	if !ok {
+		t.Fatalf("a ddl error is expected, got: %v", err)
+	}
+	derr.SetFile("test.rview")
+
+	diag := derr.Diagnostic()
+	if diag.Code != "tpl.duplicateAttribute" || diag.File != "test.rview" {
+		t.Fatalf("unexpected diagnostic: %v", diag)
+	}
+	if diag.Span == nil || diag.Span.Start.Line != 2 || diag.Span.Start.Col != 14 {
+		t.Fatalf("unexpected span: %v", diag.Span)
+	}
+	if len(diag.Notes) != 1 || diag.Notes[0].Span == nil || diag.Notes[0].Span.Start.Col != 8 {
+		t.Fatalf("a note pointing at the previous definition is expected, got: %v", diag.Notes)
+	}
+
+	bytes, jerr := diag.JSON()
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	if !strings.Contains(string(bytes), `"severity":"error"`) || !strings.Contains(string(bytes), `"line":2`) {
+		t.Fatalf("unexpected json: %s", bytes)
+	}
+
+	rendered := diag.Render(false)
+	t.Log(rendered)
+	if !strings.Contains(rendered, "--> test.rview:2:14") || !strings.Contains(rendered, "note: previously defined here") {
+		t.Fatalf("unexpected rendering: %s", rendered)
+	}
+}
+
+func TestWrapDdlError(t *testing.T) {
+	cause := errors.New("failed")
+	err := WrapDdlError("", -1, cause)
+	if err.GetEtype() != "ddl.wrapped" || strings.TrimSpace(err.Error()) != "failed" || !errors.Is(err, cause) {
+		t.Fatalf("unexpected wrapped error: %s, %v", err.GetEtype(), err)
+	}
+
+	typed := tperr.NewTypedError("exp.integerOverflow", "1e100")
+	err = WrapDdlError("", -1, typed)
+	if err.GetEtype() != "exp.integerOverflow" || err.Error() != typed.Error()+"\n" || !errors.Is(err, tperr.ErrExpIntegerOverflow) {
+		t.Fatalf("unexpected wrapped error: %s, %v", err.GetEtype(), err)
+	}
+}
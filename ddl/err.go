@@ -10,7 +10,18 @@ import (
 
 type DdlError struct {
 	pos   int
+	end   int
 	ddl   string
+	file  string
+	etype string
+	vars  []any
+	notes []ddlNote
+	cause error
+}
+
+// a note related to an error, like the place where a duplicate attribute was first defined
+type ddlNote struct {
+	pos   int
 	etype string
 	vars  []any
 }
@@ -20,10 +31,24 @@ func NewDdlError(ddl string, pos int, etype string, vars ...any) *DdlError {
 		ddl:   ddl,
 		etype: etype,
 		pos:   pos,
+		end:   -1,
 		vars:  vars,
 	}
 }
 
+// create an error at the position for another error, keeping the type and vars of a typed error.
+// any other error is of the type ddl.wrapped, with itself as the var.
+func WrapDdlError(ddl string, pos int, err error) *DdlError {
+	derr := NewDdlError(ddl, pos, "ddl.wrapped", err)
+	if typed, ok := err.(tperr.Typed); ok {
+		derr.etype = typed.GetEtype()
+		derr.vars = typed.GetVars()
+	}
+	derr.cause = err
+
+	return derr
+}
+
 func (de *DdlError) Error() string {
 	msg := ""
 
@@ -75,6 +100,67 @@ func (de *DdlError) Error() string {
 
 func (de *DdlError) AddOffset(offset int) {
 	de.pos += offset
+	if de.end >= 0 {
+		de.end += offset
+	}
+	for idx := range de.notes {
+		de.notes[idx].pos += offset
+	}
+}
+
+// set where the erroneous part ends. by default, it ends with the word or the character at the position.
+func (de *DdlError) SetEnd(end int) {
+	de.end = end
+}
+
+func (de *DdlError) SetFile(file string) {
+	de.file = file
+}
+
+func (de *DdlError) AddNote(pos int, etype string, vars ...any) {
+	de.notes = append(de.notes, ddlNote{
+		pos:   pos,
+		etype: etype,
+		vars:  vars,
+	})
+}
+
+func (de *DdlError) Diagnostic() *tperr.Diagnostic {
+	if de.pos < 0 || de.ddl == "" {
+		return tperr.NewDiagnostic("", -1, -1, tperr.SeverityError, de.etype, de.vars...).SetFile(de.file)
+	}
+
+	diag := tperr.NewDiagnostic(de.ddl, de.pos, de.endPos(), tperr.SeverityError, de.etype, de.vars...).SetFile(de.file)
+	for _, note := range de.notes {
+		diag.AddNote(note.pos, wordEnd(de.ddl, note.pos), note.etype, note.vars...)
+	}
+
+	return diag
+}
+
+func (de *DdlError) endPos() int {
+	if de.end >= 0 {
+		return de.end
+	}
+
+	return wordEnd(de.ddl, de.pos)
+}
+
+// the end of the word at the position, or the position of the next character if there is no word
+func wordEnd(str string, pos int) int {
+	end := pos
+	for end < len(str) {
+		ch := str[end]
+		if ch != '_' && ch != '-' && !(ch >= 'a' && ch <= 'z') && !(ch >= 'A' && ch <= 'Z') && !(ch >= '0' && ch <= '9') {
+			break
+		}
+		end += 1
+	}
+	if end == pos && end < len(str) {
+		end += 1
+	}
+
+	return end
 }
 
 func (de *DdlError) SetDdl(ddl string) {
@@ -85,10 +171,37 @@ func (de *DdlError) SetPos(pos int) {
 	de.pos = pos
 }
 
-func (de *DdlError) Is(etype string) bool {
+func (de *DdlError) GetPos() int {
+	return de.pos
+}
+
+func (de *DdlError) GetEtype() string {
+	return de.etype
+}
+
+func (de *DdlError) GetVars() []any {
+	return de.vars
+}
+
+func (de *DdlError) IsType(etype string) bool {
 	return etype == de.etype
 }
 
+// make errors.Is(err, tperr.ErrXXX) match any error of the same type
+func (de *DdlError) Is(target error) bool {
+	typed, ok := target.(tperr.Typed)
+	return ok && typed.GetEtype() == de.etype
+}
+
+// make errors.As fill the detail structs, like *tperr.OperandTypeError
+func (de *DdlError) As(target any) bool {
+	return tperr.AsDetail(de.etype, de.vars, target)
+}
+
+func (de *DdlError) Unwrap() error {
+	return de.cause
+}
+
 func (de *DdlError) IsExpError() bool {
 	return strings.HasPrefix(de.etype, "exp")
 }
@@ -1,7 +1,6 @@
 package ddl
 
 import (
-	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -24,36 +23,43 @@ var (
 		variable:     regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*"),
 		intNum:       regexp.MustCompile("^[0-9]+"),
 		floatNum:     regexp.MustCompile(`^[0-9]+\.[0-9]+`),
-		operator:     regexp.MustCompile(`^(\{|\}|\(|\)|\[|\]|\+|-|\*|/|%|==|!=|>=|<=|>|<|&&|\|\||!|,|\.|:|;|\?)`),
+		operator:     regexp.MustCompile(`^(\{|\}|\(|\)|\[|\]|\+\+|\+|--|-|\*|/|%|==|!=|>=|<=|=|>|<|&&|\|\||\||!|,|\.|:|;|\?\.|\?\?|\?)`),
 		function:     regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)\(`),
 		whitespace:   regexp.MustCompile(`^\s+`),
 	}
 
 	operatorPriority = map[string]int{
-		".": 6,
+		".":  7,
+		"?.": 7,
 
-		"!":        5,
-		"negative": 5,
+		"!":        6,
+		"negative": 6,
 
-		"*": 4,
-		"/": 4,
-		"%": 4,
+		"*": 5,
+		"/": 5,
+		"%": 5,
 
-		"+": 3,
-		"-": 3,
+		"+": 4,
+		"-": 4,
 
-		">":  2,
-		">=": 2,
-		"<":  2,
-		"<=": 2,
-		"==": 2,
-		"!=": 2,
+		">":  3,
+		">=": 3,
+		"<":  3,
+		"<=": 3,
+		"==": 3,
+		"!=": 3,
 
-		"&&": 1,
-		"||": 1,
+		"&&": 2,
+		"||": 2,
+
+		// a ?? b, which is b only if a is nil
+		"??": 1,
 
 		"?": -1,
 		":": 0,
+
+		// value | filter(args...), which applies to the whole expression before it
+		"|": -2,
 	}
 )
 
@@ -68,6 +74,7 @@ type Exp struct {
 	Variable        string
 	Operator        string
 	Map             map[string]*Exp
+	Array           []*Exp
 	Left            *Exp
 	Right           *Exp
 	TenaryCondition *Exp
@@ -89,6 +96,7 @@ const (
 	ExpCalc
 	ExpMap
 	ExpInterface
+	ExpArray
 )
 
 var ExpTypeName = map[ExpType]string{
@@ -103,6 +111,7 @@ var ExpTypeName = map[ExpType]string{
 	ExpCalc:      "calculation",
 	ExpMap:       "map",
 	ExpInterface: "interface",
+	ExpArray:     "array",
 }
 
 type ExpOperatorDirection int
@@ -198,7 +207,7 @@ func readExp(str string) ([]Exp, error) {
 		} else if matches := expPattern.variable.FindStringSubmatch(left); len(matches) > 0 {
 			variable := matches[0]
 			count := len(exps)
-			if count > 0 && exps[count-1].Type == ExpOperator && exps[count-1].Operator == "." {
+			if count > 0 && exps[count-1].Type == ExpOperator && (exps[count-1].Operator == "." || exps[count-1].Operator == "?.") {
 				exps = append(exps, Exp{
 					Type: ExpStr,
 					Str:  variable,
@@ -225,17 +234,34 @@ func readExp(str string) ([]Exp, error) {
 
 			// int
 		} else if matches := expPattern.intNum.FindStringSubmatch(left); len(matches) > 0 {
-			num, _ := strconv.ParseInt(matches[0], 10, 64)
-			exps = append(exps, Exp{
-				Type: ExpInt,
-				Int:  num,
-				Pos:  pos,
-			})
+			num, err := strconv.ParseInt(matches[0], 10, 64)
+			last := len(exps) - 1
+			if err == nil {
+				exps = append(exps, Exp{
+					Type: ExpInt,
+					Int:  num,
+					Pos:  pos,
+				})
+			} else if min, merr := strconv.ParseInt("-"+matches[0], 10, 64); merr == nil && last >= 0 && exps[last].Type == ExpOperator && exps[last].Operator == "negative" {
+				// -9223372036854775808 is in range, though its magnitude isn't, so the minus is taken as a part of it
+				exps[last] = Exp{
+					Type: ExpInt,
+					Int:  min,
+					Pos:  exps[last].Pos,
+				}
+			} else {
+				return exps, NewDdlError(str, pos, "exp.integerOverflow", matches[0])
+			}
 			pos += len(matches[0])
 
 			// operator
 		} else if matches := expPattern.operator.FindStringSubmatch(left); len(matches) > 0 {
 			optr := matches[0]
+			// a++ and a-- only end a statement, so 1--1 is still 1 - (-1)
+			if (optr == "++" || optr == "--") && !endsStatement(left[2:]) {
+				optr = optr[:1]
+			}
+			optrLen := len(optr)
 			if optr == "-" {
 				if len(exps) == 0 {
 					optr = "negative"
@@ -251,7 +277,7 @@ func readExp(str string) ([]Exp, error) {
 				Operator: optr,
 				Pos:      pos,
 			})
-			pos += len(matches[0])
+			pos += optrLen
 
 			// space
 		} else if matches := expPattern.whitespace.FindStringSubmatch(left); len(matches) > 0 {
@@ -282,53 +308,69 @@ func generateExpTree(exps []Exp) (*Exp, error) {
 
 		exp := exps[pos]
 
-		// {...}
+		// {key: val, ...}
 		if exp.Type == ExpOperator && exp.Operator == "{" {
-			bracketEnd := pos
-			bracketNum := 1
-			key := ""
-			valBegin := 0
-			valEnd := 0
+			bracketEnd := findClosingBracket(exps, pos)
+			if bracketEnd < 0 {
+				return nil, NewDdlError("", exp.Pos, "exp.mismatchedCurlyBrace")
+			}
+
 			expMap := make(map[string]*Exp)
-			for idx := pos + 1; idx < len(exps); idx++ {
-				if exps[idx].Type == ExpOperator && exps[idx].Operator == "{" {
-					bracketNum += 1
-				} else if exps[idx].Type == ExpOperator && exps[idx].Operator == "}" {
-					bracketNum -= 1
-					valEnd = idx - 1
-				} else if exps[idx].Type == ExpOperator && exps[idx].Operator == ":" {
-					if exps[idx-1].Type != ExpVar {
-						return nil, errors.New("there must be a key before ':'")
-					}
-					if exps[idx-2].Type != ExpOperator || (exps[idx-2].Operator != "{" && exps[idx-2].Operator != ";") {
-						return nil, errors.New("invalid key before ':'")
-					}
-					key = exps[idx-1].Variable
-					valBegin = idx + 1
-				} else if exps[idx].Type == ExpOperator && exps[idx].Operator == ";" {
-					valEnd = idx - 1
+			entries, err := splitElements(exps[pos+1:bracketEnd], exps[bracketEnd].Pos, ",;")
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				key := ""
+				if entry[0].Type == ExpVar {
+					key = entry[0].Variable
+				} else if entry[0].Type == ExpStr {
+					key = entry[0].Str
+				} else {
+					return nil, NewDdlError("", entry[0].Pos, "exp.invalidMapKey")
 				}
-
-				if bracketNum <= 1 && valEnd-valBegin >= 0 && key != "" {
-					val, err := generateExpTree(exps[valBegin : valEnd+1])
-					if err != nil {
-						return nil, err
-					}
-					expMap[key] = val
-					key = ""
+				if len(entry) < 3 || entry[1].Type != ExpOperator || entry[1].Operator != ":" {
+					return nil, NewDdlError("", entry[0].Pos, "exp.invalidMapEntry")
 				}
-				if bracketNum == 0 {
-					bracketEnd = idx
-					break
+
+				val, verr := generateExpTree(entry[2:])
+				if verr != nil {
+					return nil, verr
 				}
-			}
-			if bracketNum > 0 {
-				return nil, NewDdlError("", exp.Pos, "exp.mismatchedCurlyBrace")
+				expMap[key] = val
 			}
 
 			opndStack = append(opndStack, &Exp{
 				Type: ExpMap,
 				Map:  expMap,
+				Pos:  exp.Pos,
+			})
+			pos = bracketEnd + 1
+
+			// [a, b, ...]
+		} else if exp.Type == ExpOperator && exp.Operator == "[" && !followsOperand(exps, pos) {
+			bracketEnd := findClosingBracket(exps, pos)
+			if bracketEnd < 0 {
+				return nil, NewDdlError("", exp.Pos, "exp.mismatchedSquareBracket")
+			}
+
+			elements, err := splitElements(exps[pos+1:bracketEnd], exps[bracketEnd].Pos, ",")
+			if err != nil {
+				return nil, err
+			}
+			arr := make([]*Exp, 0, len(elements))
+			for _, element := range elements {
+				item, ierr := generateExpTree(element)
+				if ierr != nil {
+					return nil, ierr
+				}
+				arr = append(arr, item)
+			}
+
+			opndStack = append(opndStack, &Exp{
+				Type:  ExpArray,
+				Array: arr,
+				Pos:   exp.Pos,
 			})
 			pos = bracketEnd + 1
 
@@ -381,12 +423,17 @@ func generateExpTree(exps []Exp) (*Exp, error) {
 			if err != nil {
 				return nil, err
 			}
+			opndStack, optrStack, err = popMemberAccess(opndStack, optrStack)
+			if err != nil {
+				return nil, err
+			}
 			lastOpnd := opndStack[len(opndStack)-1]
 			opndStack[len(opndStack)-1] = &Exp{
 				Type:     ExpCalc,
 				Operator: "[",
 				Left:     lastOpnd,
 				Right:    parsedExp,
+				Pos:      exp.Pos,
 			}
 			/*
 				opndStack = append(opndStack, parsedExp)
@@ -400,8 +447,11 @@ func generateExpTree(exps []Exp) (*Exp, error) {
 			bracketNum := 1
 			args := make([]*Exp, 0)
 			for idx := pos + 1; idx < len(exps); idx++ {
-				if (exps[idx].Type == ExpOperator && exps[idx].Operator == "(") || exps[idx].Type == ExpFunc {
+				// the brackets of array and map literals are counted too, so that their commas don't separate the arguments
+				if (exps[idx].Type == ExpOperator && (exps[idx].Operator == "(" || exps[idx].Operator == "[" || exps[idx].Operator == "{")) || exps[idx].Type == ExpFunc {
 					bracketNum += 1
+				} else if exps[idx].Type == ExpOperator && (exps[idx].Operator == "]" || exps[idx].Operator == "}") {
+					bracketNum -= 1
 				} else if exps[idx].Type == ExpOperator && exps[idx].Operator == ")" {
 					bracketNum -= 1
 					if bracketNum == 0 && idx > argBegin {
@@ -436,15 +486,46 @@ func generateExpTree(exps []Exp) (*Exp, error) {
 				return nil, NewDdlError("", exp.Pos+len(exp.FuncName), "exp.mismatchedParenthesis")
 			}
 
+			// a?.[...]
+		} else if exp.Type == ExpOperator && exp.Operator == "?." && pos+1 < len(exps) && exps[pos+1].Type == ExpOperator && exps[pos+1].Operator == "[" {
+			bracketEnd := findClosingBracket(exps, pos+1)
+			if bracketEnd < 0 {
+				return nil, NewDdlError("", exps[pos+1].Pos, "exp.mismatchedSquareBracket")
+			}
+			if len(opndStack) == 0 {
+				return nil, NewDdlError("", exp.Pos, "exp.incompleteExpression")
+			}
+			parsedExp, err := generateExpTree(exps[pos+2 : bracketEnd])
+			if err != nil {
+				return nil, err
+			}
+			opndStack, optrStack, err = popMemberAccess(opndStack, optrStack)
+			if err != nil {
+				return nil, err
+			}
+			lastOpnd := opndStack[len(opndStack)-1]
+			opndStack[len(opndStack)-1] = &Exp{
+				Type:     ExpCalc,
+				Operator: "?.[",
+				Left:     lastOpnd,
+				Right:    parsedExp,
+				Pos:      exp.Pos,
+			}
+			pos = bracketEnd + 1
+
 			// operator
 		} else if exp.Type == ExpOperator {
+			if isStatementOperator(exp.Operator) {
+				return nil, NewDdlError("", exp.Pos, "exp.statementNotAllowed", exp.Operator)
+			}
 			if len(optrStack) == 0 {
 				optrStack = append(optrStack, &exp)
 				pos += 1
 			} else {
 				lastOptr := optrStack[len(optrStack)-1].Operator
 				curOptr := exp.Operator
-				if operatorPriority[curOptr] <= operatorPriority[lastOptr] {
+				// a prefix operator has no left operand, so it never completes the operators before it
+				if !isPrefixOperator(curOptr) && operatorPriority[curOptr] <= operatorPriority[lastOptr] {
 					var err error
 					opndStack, optrStack, err = popAndAssembleNode(opndStack, optrStack)
 					if err != nil {
@@ -478,6 +559,101 @@ func generateExpTree(exps []Exp) (*Exp, error) {
 	return opndStack[0], nil
 }
 
+// find the bracket closing the one at exps[begin], or -1 if there isn't
+func findClosingBracket(exps []Exp, begin int) int {
+	depth := 0
+	for idx := begin; idx < len(exps); idx++ {
+		if exps[idx].Type == ExpFunc {
+			depth += 1
+		} else if exps[idx].Type == ExpOperator {
+			switch exps[idx].Operator {
+			case "(", "[", "{":
+				depth += 1
+			case ")", "]", "}":
+				depth -= 1
+			}
+		}
+		if depth == 0 {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// split the elements of an array or a map literal by the separators outside of any brackets.
+// a trailing separator is allowed, but an empty element is not.
+func splitElements(exps []Exp, endPos int, seps string) ([][]Exp, error) {
+	elements := [][]Exp{}
+	depth := 0
+	begin := 0
+	for idx := 0; idx < len(exps); idx++ {
+		if exps[idx].Type == ExpFunc {
+			depth += 1
+			continue
+		}
+		if exps[idx].Type != ExpOperator {
+			continue
+		}
+
+		switch exps[idx].Operator {
+		case "(", "[", "{":
+			depth += 1
+		case ")", "]", "}":
+			depth -= 1
+		default:
+			if depth == 0 && len(exps[idx].Operator) == 1 && strings.Contains(seps, exps[idx].Operator) {
+				if idx == begin {
+					return nil, NewDdlError("", exps[idx].Pos, "exp.expectingElement")
+				}
+				elements = append(elements, exps[begin:idx])
+				begin = idx + 1
+			}
+		}
+	}
+	if begin < len(exps) {
+		elements = append(elements, exps[begin:])
+	}
+
+	return elements, nil
+}
+
+// whether the token at exps[pos] comes right after an operand, e.g. the "[" of "a[0]" rather than of "[1, 2]"
+func followsOperand(exps []Exp, pos int) bool {
+	if pos == 0 {
+		return false
+	}
+
+	prev := exps[pos-1]
+	if prev.Type != ExpOperator {
+		return true
+	}
+
+	return prev.Operator == ")" || prev.Operator == "]" || prev.Operator == "}"
+}
+
+// assemble the pending "." and "?." before an index, so that a.b[0] is (a.b)[0]
+func popMemberAccess(opndStack []*Exp, optrStack []*Exp) ([]*Exp, []*Exp, error) {
+	for len(optrStack) > 0 {
+		lastOptr := optrStack[len(optrStack)-1].Operator
+		if lastOptr != "." && lastOptr != "?." {
+			break
+		}
+
+		var err error
+		opndStack, optrStack, err = popAndAssembleNode(opndStack, optrStack)
+		if err != nil {
+			return opndStack, optrStack, err
+		}
+	}
+
+	return opndStack, optrStack, nil
+}
+
+func isPrefixOperator(optr string) bool {
+	return optr == "negative" || optr == "!"
+}
+
 func popAndAssembleNode(opndStack []*Exp, optrStack []*Exp) ([]*Exp, []*Exp, error) {
 	if len(optrStack) == 0 {
 		return opndStack, optrStack, nil
@@ -492,7 +668,7 @@ func popAndAssembleNode(opndStack []*Exp, optrStack []*Exp) ([]*Exp, []*Exp, err
 		return opndStack, optrStack, NewDdlError("", optr.Pos, "exp.mismatchedSquareBracket")
 
 	case "}":
-		return opndStack, optrStack, NewDdlError("", optr.Pos, "exp.mismatchedCurlyBracket")
+		return opndStack, optrStack, NewDdlError("", optr.Pos, "exp.mismatchedCurlyBrace")
 
 	case "negative":
 		if len(opndStack) == 0 {
@@ -545,6 +721,26 @@ func popAndAssembleNode(opndStack []*Exp, optrStack []*Exp) ([]*Exp, []*Exp, err
 		opndStack = append(opndStack, nexp)
 		optrStack = optrStack[:len(optrStack)-1]
 
+	case "|":
+		if len(opndStack) < 2 {
+			return opndStack, optrStack, NewDdlError("", optr.Pos, "exp.incompleteExpression")
+		}
+		exp1 := opndStack[len(opndStack)-1]
+		exp2 := opndStack[len(opndStack)-2]
+		if exp1.Type != ExpVar && exp1.Type != ExpFunc {
+			return opndStack, optrStack, NewDdlError("", optr.Pos, "exp.invalidFilter")
+		}
+		nexp := &Exp{
+			Type:     ExpCalc,
+			Operator: "|",
+			Left:     exp2,
+			Right:    exp1,
+			Pos:      optr.Pos,
+		}
+		opndStack = opndStack[:len(opndStack)-2]
+		opndStack = append(opndStack, nexp)
+		optrStack = optrStack[:len(optrStack)-1]
+
 	default:
 		if len(opndStack) < 2 {
 			return opndStack, optrStack, NewDdlError("", optr.Pos, "exp.incompleteExpression")
@@ -586,6 +782,147 @@ func ParseExp(str string) (*Exp, error) {
 	return tree, nil
 }
 
+// whether the rest of a statement is empty, like what is after "a++" in "a++; b = 1"
+func endsStatement(rest string) bool {
+	rest = strings.TrimLeft(rest, " \t\r\n")
+	return rest == "" || rest[0] == ';'
+}
+
+// the operators of the statements, which can't be a part of an expression
+func isStatementOperator(optr string) bool {
+	return optr == "=" || optr == "++" || optr == "--"
+}
+
+// parse the statements of an event handler, like "Count++" or "Selected = idx; Open = true".
+// the statements are separated by ";", and each one is an assignment, an increment, a decrement or an expression.
+// "a; b" is assembled like a binary operation, whose result is that of the last statement.
+func ParseStmt(str string) (*Exp, error) {
+	if trim(str) == "" {
+		return nil, nil
+	}
+
+	exps, err := readExp(str)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err2 := generateStmtTree(exps, len(str))
+	if err2 != nil {
+		if tpe, ok := err2.(*DdlError); ok {
+			tpe.SetDdl(str)
+		}
+		return nil, err2
+	}
+
+	return tree, nil
+}
+
+func generateStmtTree(exps []Exp, endPos int) (*Exp, error) {
+	stmts, err := splitElements(exps, endPos, ";")
+	if err != nil {
+		return nil, err
+	}
+
+	tree := (*Exp)(nil)
+	for _, stmt := range stmts {
+		node, serr := generateStmt(stmt)
+		if serr != nil {
+			return nil, serr
+		}
+
+		if tree == nil {
+			tree = node
+		} else {
+			tree = &Exp{
+				Type:     ExpCalc,
+				Operator: ";",
+				Left:     tree,
+				Right:    node,
+				Pos:      node.Pos,
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+func generateStmt(exps []Exp) (*Exp, error) {
+	// a++, a--
+	last := exps[len(exps)-1]
+	if last.Type == ExpOperator && (last.Operator == "++" || last.Operator == "--") {
+		if len(exps) == 1 {
+			return nil, NewDdlError("", last.Pos, "exp.incompleteExpression")
+		}
+		target, err := generateAssignmentTarget(exps[:len(exps)-1])
+		if err != nil {
+			return nil, err
+		}
+		return &Exp{
+			Type:     ExpCalc,
+			Operator: last.Operator,
+			Left:     target,
+			Pos:      last.Pos,
+		}, nil
+	}
+
+	// a = b, where "=" is looked for outside of any brackets
+	depth := 0
+	for idx, exp := range exps {
+		if exp.Type == ExpFunc {
+			depth += 1
+			continue
+		}
+		if exp.Type != ExpOperator {
+			continue
+		}
+
+		switch exp.Operator {
+		case "(", "[", "{":
+			depth += 1
+		case ")", "]", "}":
+			depth -= 1
+		case "=":
+			if depth > 0 {
+				continue
+			}
+			if idx == 0 || idx == len(exps)-1 {
+				return nil, NewDdlError("", exp.Pos, "exp.incompleteExpression")
+			}
+			target, err := generateAssignmentTarget(exps[:idx])
+			if err != nil {
+				return nil, err
+			}
+			val, err := generateExpTree(exps[idx+1:])
+			if err != nil {
+				return nil, err
+			}
+			return &Exp{
+				Type:     ExpCalc,
+				Operator: "=",
+				Left:     target,
+				Right:    val,
+				Pos:      exp.Pos,
+			}, nil
+		}
+	}
+
+	return generateExpTree(exps)
+}
+
+// only a variable, a field, a map entry or an element can be assigned to, like a, a.b or a[0]
+func generateAssignmentTarget(exps []Exp) (*Exp, error) {
+	target, err := generateExpTree(exps)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Type == ExpVar || (target.Type == ExpCalc && (target.Operator == "." || target.Operator == "[")) {
+		return target, nil
+	}
+
+	return nil, NewDdlError("", exps[0].Pos, "exp.invalidAssignmentTarget")
+}
+
 func (a *Exp) Equal(b *Exp) bool {
 	if a == nil && b == nil {
 		return true
@@ -654,6 +991,16 @@ func (a *Exp) Equal(b *Exp) bool {
 			}
 		}
 
+	case ExpArray:
+		if len(a.Array) != len(b.Array) {
+			return false
+		}
+		for i := 0; i < len(a.Array); i++ {
+			if !a.Array[i].Equal(b.Array[i]) {
+				return false
+			}
+		}
+
 	case ExpCalc:
 		if a.Operator != b.Operator ||
 			(a.Left == nil && b.Left != nil) ||
@@ -688,7 +1035,11 @@ func (e *Exp) TypeName() string {
 
 func (e *Exp) ActualTypeName() string {
 	if e.Type == ExpInterface {
-		return reflect.TypeOf(e.Interface).Name()
+		// unnamed types, like []int, only have a string form
+		if name := reflect.TypeOf(e.Interface).Name(); name != "" {
+			return name
+		}
+		return reflect.TypeOf(e.Interface).String()
 	}
 
 	return ExpTypeName[e.Type]
@@ -723,6 +1074,9 @@ func (e *Exp) ToString() string {
 	case ExpMap:
 		return fmt.Sprintf("%v", e.Map)
 
+	case ExpArray:
+		return fmt.Sprintf("%v", e.Array)
+
 	case ExpVar:
 		return fmt.Sprintf("variable: %s", e.Variable)
 	}
@@ -855,6 +855,288 @@ var (
 				},
 			},
 		},
+		{
+			str: `{a: 1, "b c": x ? 1 : 2, d: {e: [1]},}`,
+			exp: Exp{
+				Type: ExpMap,
+				Map: map[string]*Exp{
+					"a": {
+						Type: ExpInt,
+						Int:  1,
+					},
+					"b c": {
+						Type:     ExpCalc,
+						Operator: "?",
+						TenaryCondition: &Exp{
+							Type:     ExpVar,
+							Variable: "x",
+						},
+						Left: &Exp{
+							Type: ExpInt,
+							Int:  1,
+						},
+						Right: &Exp{
+							Type: ExpInt,
+							Int:  2,
+						},
+					},
+					"d": {
+						Type: ExpMap,
+						Map: map[string]*Exp{
+							"e": {
+								Type: ExpArray,
+								Array: []*Exp{
+									{
+										Type: ExpInt,
+										Int:  1,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			str: "[]",
+			exp: Exp{
+				Type:  ExpArray,
+				Array: []*Exp{},
+			},
+		},
+		{
+			str: "[1, a + 1, func1(b, c),]",
+			exp: Exp{
+				Type: ExpArray,
+				Array: []*Exp{
+					{
+						Type: ExpInt,
+						Int:  1,
+					},
+					{
+						Type:     ExpCalc,
+						Operator: "+",
+						Left: &Exp{
+							Type:     ExpVar,
+							Variable: "a",
+						},
+						Right: &Exp{
+							Type: ExpInt,
+							Int:  1,
+						},
+					},
+					{
+						Type:     ExpFunc,
+						FuncName: "func1",
+						FuncParams: []*Exp{
+							{
+								Type:     ExpVar,
+								Variable: "b",
+							},
+							{
+								Type:     ExpVar,
+								Variable: "c",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			str: "[[1], a][1]",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "[",
+				Left: &Exp{
+					Type: ExpArray,
+					Array: []*Exp{
+						{
+							Type: ExpArray,
+							Array: []*Exp{
+								{
+									Type: ExpInt,
+									Int:  1,
+								},
+							},
+						},
+						{
+							Type:     ExpVar,
+							Variable: "a",
+						},
+					},
+				},
+				Right: &Exp{
+					Type: ExpInt,
+					Int:  1,
+				},
+			},
+		},
+		{
+			str: "Name | upper",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "|",
+				Left: &Exp{
+					Type:     ExpVar,
+					Variable: "Name",
+				},
+				Right: &Exp{
+					Type:     ExpVar,
+					Variable: "upper",
+				},
+			},
+		},
+		{
+			str: "a || b ? c : d | currency('USD') | padLeft(10)",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "|",
+				Left: &Exp{
+					Type:     ExpCalc,
+					Operator: "|",
+					Left: &Exp{
+						Type:     ExpCalc,
+						Operator: "?",
+						TenaryCondition: &Exp{
+							Type:     ExpCalc,
+							Operator: "||",
+							Left: &Exp{
+								Type:     ExpVar,
+								Variable: "a",
+							},
+							Right: &Exp{
+								Type:     ExpVar,
+								Variable: "b",
+							},
+						},
+						Left: &Exp{
+							Type:     ExpVar,
+							Variable: "c",
+						},
+						Right: &Exp{
+							Type:     ExpVar,
+							Variable: "d",
+						},
+					},
+					Right: &Exp{
+						Type:     ExpFunc,
+						FuncName: "currency",
+						FuncParams: []*Exp{
+							{
+								Type: ExpStr,
+								Str:  "USD",
+							},
+						},
+					},
+				},
+				Right: &Exp{
+					Type:     ExpFunc,
+					FuncName: "padLeft",
+					FuncParams: []*Exp{
+						{
+							Type: ExpInt,
+							Int:  10,
+						},
+					},
+				},
+			},
+		},
+		{
+			str: "a?.b.c",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: ".",
+				Left: &Exp{
+					Type:     ExpCalc,
+					Operator: "?.",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "a",
+					},
+					Right: &Exp{
+						Type: ExpStr,
+						Str:  "b",
+					},
+				},
+				Right: &Exp{
+					Type: ExpStr,
+					Str:  "c",
+				},
+			},
+		},
+		{
+			str: "a.b?.[0]",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "?.[",
+				Left: &Exp{
+					Type:     ExpCalc,
+					Operator: ".",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "a",
+					},
+					Right: &Exp{
+						Type: ExpStr,
+						Str:  "b",
+					},
+				},
+				Right: &Exp{
+					Type: ExpInt,
+					Int:  0,
+				},
+			},
+		},
+		{
+			str: "a ?? b || c",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "??",
+				Left: &Exp{
+					Type:     ExpVar,
+					Variable: "a",
+				},
+				Right: &Exp{
+					Type:     ExpCalc,
+					Operator: "||",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "b",
+					},
+					Right: &Exp{
+						Type:     ExpVar,
+						Variable: "c",
+					},
+				},
+			},
+		},
+		{
+			str: "a?1:b?.c",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "?",
+				TenaryCondition: &Exp{
+					Type:     ExpVar,
+					Variable: "a",
+				},
+				Left: &Exp{
+					Type: ExpInt,
+					Int:  1,
+				},
+				Right: &Exp{
+					Type:     ExpCalc,
+					Operator: "?.",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "b",
+					},
+					Right: &Exp{
+						Type: ExpStr,
+						Str:  "c",
+					},
+				},
+			},
+		},
 		{
 			str: "var1 ? a : b",
 			exp: Exp{
@@ -1092,6 +1374,221 @@ var (
 			str: "func1(a, func2(a)",
 			err: "exp.mismatchedParenthesis",
 		},
+		{
+			str: "[1, 2",
+			err: "exp.mismatchedSquareBracket",
+		},
+		{
+			str: "a | 1",
+			err: "exp.invalidFilter",
+		},
+		{
+			str: "a | upper + 1",
+			err: "exp.invalidFilter",
+		},
+		{
+			str: "a |",
+			err: "exp.incompleteExpression",
+		},
+		{
+			str: "[1,,2]",
+			err: "exp.expectingElement",
+		},
+		{
+			str: "{1: 2}",
+			err: "exp.invalidMapKey",
+		},
+		{
+			str: "{a 1}",
+			err: "exp.invalidMapEntry",
+		},
+		{
+			str: "1--1",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "-",
+				Left: &Exp{
+					Type: ExpInt,
+					Int:  1,
+				},
+				Right: &Exp{
+					Type:     ExpCalc,
+					Operator: "-",
+					Right: &Exp{
+						Type: ExpInt,
+						Int:  1,
+					},
+				},
+			},
+		},
+		{
+			str: "a = 1",
+			err: "exp.statementNotAllowed",
+		},
+		{
+			str: "a++",
+			err: "exp.statementNotAllowed",
+		},
+	}
+
+	parseStmtTestCases = []parseExpTestCase{
+		{
+			str: "Count = Count + 1",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "=",
+				Left: &Exp{
+					Type:     ExpVar,
+					Variable: "Count",
+				},
+				Right: &Exp{
+					Type:     ExpCalc,
+					Operator: "+",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "Count",
+					},
+					Right: &Exp{
+						Type: ExpInt,
+						Int:  1,
+					},
+				},
+			},
+		},
+		{
+			str: "Count++",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "++",
+				Left: &Exp{
+					Type:     ExpVar,
+					Variable: "Count",
+				},
+			},
+		},
+		{
+			str: "Selected = idx; Open = true;",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: ";",
+				Left: &Exp{
+					Type:     ExpCalc,
+					Operator: "=",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "Selected",
+					},
+					Right: &Exp{
+						Type:     ExpVar,
+						Variable: "idx",
+					},
+				},
+				Right: &Exp{
+					Type:     ExpCalc,
+					Operator: "=",
+					Left: &Exp{
+						Type:     ExpVar,
+						Variable: "Open",
+					},
+					Right: &Exp{
+						Type: ExpBool,
+						Bool: true,
+					},
+				},
+			},
+		},
+		{
+			str: "items[idx].Done = !items[idx].Done",
+			exp: Exp{
+				Type:     ExpCalc,
+				Operator: "=",
+				Left: &Exp{
+					Type:     ExpCalc,
+					Operator: ".",
+					Left: &Exp{
+						Type:     ExpCalc,
+						Operator: "[",
+						Left: &Exp{
+							Type:     ExpVar,
+							Variable: "items",
+						},
+						Right: &Exp{
+							Type:     ExpVar,
+							Variable: "idx",
+						},
+					},
+					Right: &Exp{
+						Type: ExpStr,
+						Str:  "Done",
+					},
+				},
+				Right: &Exp{
+					Type:     ExpCalc,
+					Operator: "!",
+					Right: &Exp{
+						Type:     ExpCalc,
+						Operator: ".",
+						Left: &Exp{
+							Type:     ExpCalc,
+							Operator: "[",
+							Left: &Exp{
+								Type:     ExpVar,
+								Variable: "items",
+							},
+							Right: &Exp{
+								Type:     ExpVar,
+								Variable: "idx",
+							},
+						},
+						Right: &Exp{
+							Type: ExpStr,
+							Str:  "Done",
+						},
+					},
+				},
+			},
+		},
+		{
+			str: "open(a == 1)",
+			exp: Exp{
+				Type:     ExpFunc,
+				FuncName: "open",
+				FuncParams: []*Exp{
+					{
+						Type:     ExpCalc,
+						Operator: "==",
+						Left: &Exp{
+							Type:     ExpVar,
+							Variable: "a",
+						},
+						Right: &Exp{
+							Type: ExpInt,
+							Int:  1,
+						},
+					},
+				},
+			},
+		},
+		{
+			str: "a = b = 1",
+			err: "exp.statementNotAllowed",
+		},
+		{
+			str: "f() = 1",
+			err: "exp.invalidAssignmentTarget",
+		},
+		{
+			str: "a?.b = 1",
+			err: "exp.invalidAssignmentTarget",
+		},
+		{
+			str: "= 1",
+			err: "exp.incompleteExpression",
+		},
+		{
+			str: "a = 1;; b = 2",
+			err: "exp.expectingElement",
+		},
 	}
 )
 
@@ -1118,3 +1615,25 @@ func TestParseExp(t *testing.T) {
 		}
 	}
 }
+
+func TestParseStmt(t *testing.T) {
+	for _, testCase := range parseStmtTestCases {
+		t.Logf("statement: %s\n", testCase.str)
+		realExp, err := ParseStmt(testCase.str)
+		if err != nil {
+			if tpe, ok := err.(*DdlError); ok && testCase.err != "" && tpe.etype == testCase.err {
+				t.Log(tpe.Error())
+				continue
+			}
+			t.Fatalf("error: %s", err)
+		}
+		if testCase.err != "" {
+			t.Fatalf("expect the error %s", testCase.err)
+		}
+		if !realExp.Equal(&testCase.exp) {
+			spew.Dump(*realExp)
+			spew.Dump(testCase.exp)
+			t.Fatalf("the statement is not parsed as expected\n")
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ddl
 
 import (
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -19,6 +20,17 @@ const (
 	CSSTokenProp
 )
 
+var CSSTokenTypeName = map[CSSTokenType]string{
+	CSSTokenNum:      "number",
+	CSSTokenStr:      "string",
+	CSSTokenColor:    "color",
+	CSSTokenOperator: "operator",
+	CSSTokenFunc:     "function",
+	CSSTokenVar:      "variable",
+	CSSTokenClass:    "class",
+	CSSTokenProp:     "property",
+}
+
 type CSSUnit int
 
 const (
@@ -113,6 +125,23 @@ var (
 	}
 )
 
+// get the names of all the supported css properties, sorted alphabetically
+func CssPropNames() []string {
+	names := make([]string, 0, len(propRuleMap))
+	for name := range propRuleMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// get the accepted value forms of a css property
+func CssPropRule(prop string) ([][]CSSTokenType, bool) {
+	rule, ok := propRuleMap[prop]
+	return rule, ok
+}
+
 func parseCss(css string) (CSSClassMap, error) {
 	tokens, err := tokenizeCss(css)
 	if err != nil {
@@ -140,6 +169,76 @@ func parseCss(css string) (CSSClassMap, error) {
 	return classMap, nil
 }
 
+// parse css.
+// if recoverable is true, each class is parsed on its own, so that an error in one class doesn't hide the others.
+func parseCssWithRecovery(css string, recoverable bool) (CSSClassMap, []*DdlError) {
+	toDdlError := func(err error) *DdlError {
+		derr, ok := err.(*DdlError)
+		if !ok {
+			derr = WrapDdlError(css, 0, err)
+		}
+		return derr
+	}
+
+	if !recoverable {
+		classMap, err := parseCss(css)
+		if err != nil {
+			return classMap, []*DdlError{toDdlError(err)}
+		}
+		return classMap, nil
+	}
+
+	classMap := CSSClassMap{}
+	errs := []*DdlError{}
+	quote := byte(0)
+	begin := 0
+	for pos := 0; pos <= len(css); pos++ {
+		if pos < len(css) {
+			ch := css[pos]
+			if quote != 0 {
+				if ch == quote && css[pos-1] != '\\' {
+					quote = 0
+				}
+				continue
+			}
+			if ch == '\'' || ch == '"' {
+				quote = ch
+				continue
+			}
+			if strings.HasPrefix(css[pos:], "/*") {
+				if end := strings.Index(css[pos+2:], "*/"); end != -1 {
+					pos += end + 3
+					continue
+				}
+			}
+			if ch != '}' {
+				continue
+			}
+		}
+
+		end := pos + 1
+		if end > len(css) {
+			end = len(css)
+		}
+		block := css[begin:end]
+		if trim(block) != "" {
+			blockClassMap, err := parseCss(block)
+			if err != nil {
+				derr := toDdlError(err)
+				derr.AddOffset(begin)
+				derr.SetDdl(css)
+				errs = append(errs, derr)
+			}
+			for key, val := range blockClassMap {
+				classMap[key] = val
+			}
+		}
+		begin = end
+	}
+
+	return classMap, errs
+}
+
 func genCssClassMap(tokens []CSSToken) (CSSClassMap, error) {
 	classMap := make(CSSClassMap)
 
@@ -287,13 +386,24 @@ func tokenizeCss(css string) ([]CSSToken, error) {
 	byteArr := []byte(css)
 	blen := len(byteArr)
 	pos := 0
+	if blen == 0 {
+		return tokens, nil
+	}
 
 	for {
 		ch := byteArr[pos]
 		left := string(byteArr[pos:])
 
-		// string literal
-		if ch == '\'' {
+		// /* comment */
+		if strings.HasPrefix(left, "/*") {
+			end := strings.Index(left[2:], "*/")
+			if end == -1 {
+				return tokens, NewDdlError(css, pos, "css.unterminatedComment")
+			}
+			pos += end + 4
+
+			// string literal
+		} else if ch == '\'' {
 			match := false
 			for end := pos + 1; end < blen; end++ {
 				if byteArr[end] == '\'' && byteArr[end-1] != '\\' {
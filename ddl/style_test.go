@@ -21,6 +21,15 @@ type parseCssTestCase struct {
 
 var (
 	tokenizeCssTestCases = []tokenizeCssTestCase{
+		{
+			str: "/* a } 'b */+/**/",
+			tokens: []CSSToken{
+				{
+					Type:     CSSTokenOperator,
+					Operator: "+",
+				},
+			},
+		},
 		{
 			str: "+",
 			tokens: []CSSToken{
@@ -451,6 +460,10 @@ var (
 			str: ".class1 .class2",
 			err: "css.unexpectedToken",
 		},
+		{
+			str: ".class1 { /* margin: 1ch; ",
+			err: "css.unterminatedComment",
+		},
 		{
 			str: ".class1 abc",
 			err: "css.unexpectedToken",
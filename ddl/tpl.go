@@ -1,6 +1,7 @@
 package ddl
 
 import (
+	"html"
 	"regexp"
 	"strings"
 )
@@ -15,16 +16,20 @@ var (
 		attrWithoutVal    *regexp.Regexp
 		def               *regexp.Regexp
 		vfor              *regexp.Regexp
+		vforVar           *regexp.Regexp
+		directive         *regexp.Regexp
 		whitespace        *regexp.Regexp
 	}{
 		openingTagBegin:   regexp.MustCompile(`^<([a-zA-Z0-9\-]+)`),
 		openingTagEnd:     regexp.MustCompile(`^>`),
 		closingTag:        regexp.MustCompile(`^</([a-zA-Z0-9\-]+)>`),
 		selfClosingTagEnd: regexp.MustCompile(`^/>`),
-		attrStart:         regexp.MustCompile(`^([a-zA-Z0-9\-_@:]+)=`),
-		attrWithoutVal:    regexp.MustCompile(`^([a-zA-Z0-9\-]+)`),
+		attrStart:         regexp.MustCompile(`^([a-zA-Z0-9\-_@:.]+)=`),
+		attrWithoutVal:    regexp.MustCompile(`^([a-zA-Z0-9\-_@:.]+)`),
 		def:               regexp.MustCompile(`^([a-zA-Z0-9_\-]+)\((.*)\)$`),
-		vfor:              regexp.MustCompile(`^\s*\(\s*([a-zA-Z\_][a-zA-Z0-9\_]*),\s*([a-zA-Z\_][a-zA-Z0-9\_]*)\s*\)\s+of\s+(.*?)\s*$`),
+		vfor:              regexp.MustCompile(`^\s*(\(\s*[a-zA-Z\_][a-zA-Z0-9\_]*(?:\s*,\s*[a-zA-Z\_][a-zA-Z0-9\_]*){0,2}\s*\)|[a-zA-Z\_][a-zA-Z0-9\_]*)\s+of\s+(.*?)\s*$`),
+		vforVar:           regexp.MustCompile(`[a-zA-Z\_][a-zA-Z0-9\_]*`),
+		directive:         regexp.MustCompile(`^v-([a-zA-Z][a-zA-Z0-9_\-]*)(?::([a-zA-Z0-9_\-]+))?((?:\.[a-zA-Z0-9_\-]+)*)$`),
 		whitespace:        regexp.MustCompile(`^\s+`),
 	}
 )
@@ -35,8 +40,15 @@ const (
 	TplNodeTag TplNodeType = iota
 	TplNodeText
 	TplNodeExp
+	TplNodeComment
 )
 
+// the content of these tags is read as it is, without looking for tags or interpolations inside
+var rawTextTags = map[string]bool{
+	"style": true,
+	"pre":   true,
+}
+
 type TplNode struct {
 	Type       TplNodeType
 	TagName    string
@@ -45,33 +57,61 @@ type TplNode struct {
 	Idx        int
 	Events     map[string]*TplAttr
 	Attrs      map[string]*TplAttr
-	Directives map[string]string
+	Directives map[string]*TplDirective
 	Text       string
 	Exp        *Exp
 	If         *TplAttr
 	ElseIf     *TplAttr
 	Else       *TplAttr
 	For        *TplFor
+	Show       *TplAttr
+	Once       *TplAttr
+	Memo       *TplAttr
 	Def        *TplAttr
 	Pos        int
 }
 
 type TplAttr struct {
-	Pos int
-	Exp *Exp
+	Pos    int
+	ValPos int
+	Exp    *Exp
 }
 
+// v-for="item of Items", v-for="(idx, item) of Items" or v-for="(item, idx, total) of Items".
+// Idx is the index, or the key of a map, and Total is the number of the items. both are optional.
 type TplFor struct {
 	Pos      int
 	Idx      string
 	IdxPos   int
 	Val      string
 	ValPos   int
+	Total    string
+	TotalPos int
 	Range    *Exp
 	RangePos int
 }
 
-func (tn *TplNode) addAttr(pos int, key string, val string) error {
+// a custom directive, like v-tooltip="Tip", v-shortcut:save.ctrl="'s'" or v-focus.
+// the argument follows the name after ':', and each modifier follows a '.'. Exp is nil if the directive has no value.
+type TplDirective struct {
+	Pos       int
+	ValPos    int
+	Name      string
+	Arg       string
+	Modifiers []string
+	Exp       *Exp
+}
+
+// the name and the arg of a directive, like tooltip:top, which tells it apart from the others of a node
+func (td *TplDirective) Key() string {
+	if td.Arg == "" {
+		return td.Name
+	}
+
+	return td.Name + ":" + td.Arg
+}
+
+func (tn *TplNode) addAttr(pos int, valPos int, key string, val string) error {
 	// def
 	if key == "def" {
 		matches := tplPattern.def.FindStringSubmatch(val)
@@ -85,12 +125,14 @@ func (tn *TplNode) addAttr(pos int, key string, val string) error {
 		}
 		if len(matches) == 3 && trim(matches[2]) != "" {
 			str := trim(matches[2])
+			paramPos := valPos + strings.Index(val, str)
 			params := strings.Split(str, ",")
 			for _, param := range params {
 				pexp, perr := ParseExp(param)
 				if perr != nil {
-					return perr
+					return offsetExpError(perr, pos, paramPos)
 				}
+				paramPos += len(param) + 1
 				if pexp.Type != ExpVar {
 					return NewDdlError("", pos, "tpl.invalidDefAttr")
 				}
@@ -98,63 +140,116 @@ func (tn *TplNode) addAttr(pos int, key string, val string) error {
 			}
 		}
 		tn.Def = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
 		}
 
 		// v-if
 	} else if key == "v-if" {
 		exp, err := ParseExp(val)
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos)
 		}
 		if tn.If != nil {
-			return NewDdlError("", pos, "tpl.duplicateDirective")
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.If.Pos)
 		}
 		if tn.ElseIf != nil || tn.Else != nil || tn.For != nil {
 			return NewDdlError("", pos, "tpl.conflictedDirective")
 		}
 		tn.If = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
 		}
 
 		// v-else-if
 	} else if key == "v-else-if" {
 		exp, err := ParseExp(val)
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos)
 		}
 		if tn.ElseIf != nil {
-			return NewDdlError("", pos, "tpl.duplicateDirective")
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.ElseIf.Pos)
 		}
 		if tn.If != nil || tn.Else != nil || tn.For != nil {
 			return NewDdlError("", pos, "tpl.conflictedDirective")
 		}
 		tn.ElseIf = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
 		}
 
 		// v-else
 	} else if key == "v-else" {
 		if tn.Else != nil {
-			return NewDdlError("", pos, "tpl.duplicateDirective")
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.Else.Pos)
 		}
 		if tn.If != nil || tn.ElseIf != nil || tn.For != nil {
 			return NewDdlError("", pos, "tpl.conflictedDirective")
 		}
 		tn.Else = &TplAttr{
-			Pos: pos,
+			Pos:    pos,
+			ValPos: valPos,
 			Exp: &Exp{
 				Type: ExpNil,
 			},
 		}
 
+		// v-show
+	} else if key == "v-show" {
+		exp, err := ParseExp(val)
+		if err != nil {
+			return offsetExpError(err, pos, valPos)
+		}
+		if tn.Show != nil {
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.Show.Pos)
+		}
+		tn.Show = &TplAttr{
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
+		}
+
+		// v-once
+	} else if key == "v-once" {
+		if tn.Once != nil {
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.Once.Pos)
+		}
+		if tn.Memo != nil {
+			return NewDdlError("", pos, "tpl.conflictedDirective")
+		}
+		tn.Once = &TplAttr{
+			Pos:    pos,
+			ValPos: valPos,
+			Exp: &Exp{
+				Type: ExpNil,
+			},
+		}
+
+		// v-memo
+	} else if key == "v-memo" {
+		exp, err := ParseExp(val)
+		if err != nil {
+			return offsetExpError(err, pos, valPos)
+		}
+		if tn.Memo != nil {
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.Memo.Pos)
+		}
+		if tn.Once != nil {
+			return NewDdlError("", pos, "tpl.conflictedDirective")
+		}
+		tn.Memo = &TplAttr{
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
+		}
+
 		// v-for
 	} else if key == "v-for" {
 		if tn.For != nil {
-			return NewDdlError("", pos, "tpl.duplicateDirective")
+			return newDuplicateError(pos, "tpl.duplicateDirective", tn.For.Pos)
 		}
 		if tn.If != nil || tn.ElseIf != nil || tn.Else != nil {
 			return NewDdlError("", pos, "tpl.conflictedDirective")
@@ -163,19 +258,35 @@ func (tn *TplNode) addAttr(pos int, key string, val string) error {
 		if len(matches) == 0 {
 			return NewDdlError("", pos, "tpl.invalidVforDirective")
 		}
-		rangeExp, err := ParseExp(matches[3])
+		imatches := tplPattern.vfor.FindStringSubmatchIndex(val)
+		rangeExp, err := ParseExp(matches[2])
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos+imatches[4])
 		}
-		imatches := tplPattern.vfor.FindStringSubmatchIndex(val)
 		tn.For = &TplFor{
 			Pos:      pos,
-			Idx:      matches[1],
-			Val:      matches[2],
 			Range:    rangeExp,
-			IdxPos:   imatches[2],
-			ValPos:   imatches[4],
-			RangePos: imatches[6],
+			RangePos: valPos + imatches[4],
+		}
+
+		// the two variables are the index and the item, while the three are the item, the index and the total
+		names := tplPattern.vforVar.FindAllString(matches[1], -1)
+		namePoses := tplPattern.vforVar.FindAllStringIndex(matches[1], -1)
+		namePos := func(idx int) int {
+			return valPos + imatches[2] + namePoses[idx][0]
+		}
+		switch len(names) {
+		case 1:
+			tn.For.Val, tn.For.ValPos = names[0], namePos(0)
+
+		case 2:
+			tn.For.Idx, tn.For.IdxPos = names[0], namePos(0)
+			tn.For.Val, tn.For.ValPos = names[1], namePos(1)
+
+		case 3:
+			tn.For.Val, tn.For.ValPos = names[0], namePos(0)
+			tn.For.Idx, tn.For.IdxPos = names[1], namePos(1)
+			tn.For.Total, tn.For.TotalPos = names[2], namePos(2)
 		}
 
 		// v-bind:var
@@ -183,17 +294,18 @@ func (tn *TplNode) addAttr(pos int, key string, val string) error {
 		vname := key[7:]
 		exp, err := ParseExp(val)
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos)
 		}
 		if tn.Attrs == nil {
 			tn.Attrs = make(map[string]*TplAttr)
 		}
-		if _, ok := tn.Attrs[vname]; ok {
-			return NewDdlError("", pos, "tpl.duplicateAttribute")
+		if prev, ok := tn.Attrs[vname]; ok {
+			return newDuplicateError(pos, "tpl.duplicateAttribute", prev.Pos)
 		}
 		tn.Attrs[vname] = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
 		}
 
 		// :var
@@ -201,68 +313,102 @@ func (tn *TplNode) addAttr(pos int, key string, val string) error {
 		vname := key[1:]
 		exp, err := ParseExp(val)
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos)
 		}
 		if tn.Attrs == nil {
 			tn.Attrs = make(map[string]*TplAttr)
 		}
-		if _, ok := tn.Attrs[vname]; ok {
-			return NewDdlError("", pos, "tpl.duplicateAttribute")
+		if prev, ok := tn.Attrs[vname]; ok {
+			return newDuplicateError(pos, "tpl.duplicateAttribute", prev.Pos)
 		}
 		tn.Attrs[vname] = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
 		}
 
 		// v-on:event
 	} else if strings.HasPrefix(key, "v-on:") {
 		event := key[5:]
-		exp, err := ParseExp(val)
+		exp, err := ParseStmt(val)
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos)
 		}
 		if tn.Events == nil {
 			tn.Events = make(map[string]*TplAttr)
 		}
-		if _, ok := tn.Events[event]; ok {
-			return NewDdlError("", pos, "tpl.duplicateEventHandler")
+		if prev, ok := tn.Events[event]; ok {
+			return newDuplicateError(pos, "tpl.duplicateEventHandler", prev.Pos)
 		}
 		tn.Events[event] = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
 		}
 
 		// @event
 	} else if strings.HasPrefix(key, "@") {
 		event := key[1:]
-		exp, err := ParseExp(val)
+		exp, err := ParseStmt(val)
 		if err != nil {
-			return err
+			return offsetExpError(err, pos, valPos)
 		}
 		if tn.Events == nil {
 			tn.Events = make(map[string]*TplAttr)
 		}
-		if _, ok := tn.Events[event]; ok {
-			return NewDdlError("", pos, "tpl.duplicateEventHandler")
+		if prev, ok := tn.Events[event]; ok {
+			return newDuplicateError(pos, "tpl.duplicateEventHandler", prev.Pos)
 		}
 		tn.Events[event] = &TplAttr{
-			Pos: pos,
-			Exp: exp,
+			Pos:    pos,
+			ValPos: valPos,
+			Exp:    exp,
+		}
+
+		// custom directive
+	} else if strings.HasPrefix(key, "v-") {
+		matches := tplPattern.directive.FindStringSubmatch(key)
+		if len(matches) == 0 {
+			return NewDdlError("", pos, "tpl.invalidDirective", key)
+		}
+		directive := &TplDirective{
+			Pos:    pos,
+			ValPos: valPos,
+			Name:   matches[1],
+			Arg:    matches[2],
+		}
+		if matches[3] != "" {
+			directive.Modifiers = strings.Split(matches[3][1:], ".")
+		}
+		if trim(val) != "" {
+			exp, err := ParseExp(val)
+			if err != nil {
+				return offsetExpError(err, pos, valPos)
+			}
+			directive.Exp = exp
+		}
+		if tn.Directives == nil {
+			tn.Directives = make(map[string]*TplDirective)
 		}
+		if prev, ok := tn.Directives[directive.Key()]; ok {
+			return newDuplicateError(pos, "tpl.duplicateDirective", prev.Pos)
+		}
+		tn.Directives[directive.Key()] = directive
 
 		// ordinary attritube
 	} else {
 		if tn.Attrs == nil {
 			tn.Attrs = make(map[string]*TplAttr)
 		}
-		if _, ok := tn.Attrs[key]; ok {
-			return NewDdlError("", pos, "tpl.duplicateAttribute")
+		if prev, ok := tn.Attrs[key]; ok {
+			return newDuplicateError(pos, "tpl.duplicateAttribute", prev.Pos)
 		}
 		tn.Attrs[key] = &TplAttr{
-			Pos: pos,
+			Pos:    pos,
+			ValPos: valPos,
 			Exp: &Exp{
 				Type: ExpStr,
-				Str:  val,
+				Str:  html.UnescapeString(val),
 			},
 		}
 	}
@@ -270,15 +416,81 @@ func (tn *TplNode) addAttr(pos int, key string, val string) error {
 	return nil
 }
 
+func newDuplicateError(pos int, etype string, prevPos int) *DdlError {
+	err := NewDdlError("", pos, etype)
+	err.AddNote(prevPos, "tpl.note.previousDefinition")
+	return err
+}
+
+// make the position of an error raised while parsing an attribute value relative to the whole template
+func offsetExpError(err error, pos int, valPos int) error {
+	if derr, ok := err.(*DdlError); ok {
+		derr.AddOffset(valPos)
+		return derr
+	}
+
+	return WrapDdlError("", pos, err)
+}
+
 func parseTpl(tpl string) ([]*TplNode, error) {
+	nodes, errs := parseTplWithRecovery(tpl, false)
+	if len(errs) > 0 {
+		return nodes, errs[0]
+	}
+
+	return nodes, nil
+}
+
+// parse a template, keeping the comments as TplNodeComment nodes, which is useful for tools like formatters.
+// like ParseDdlWithRecovery, it doesn't stop at the first error.
+func ParseTplWithComments(tpl string) ([]*TplNode, []*DdlError) {
+	return parseTplWithOptions(tpl, true, true)
+}
+
+func parseTplWithRecovery(tpl string, recoverable bool) ([]*TplNode, []*DdlError) {
+	return parseTplWithOptions(tpl, recoverable, false)
+}
+
+// parse a template.
+// if recoverable is false, it stops at the first error.
+// otherwise, it skips the broken part, goes on parsing, and returns all the errors along with a best-effort node tree.
+// comments are dropped unless keepComments is true.
+func parseTplWithOptions(tpl string, recoverable bool, keepComments bool) ([]*TplNode, []*DdlError) {
 	curTagNode := (*TplNode)(nil)
 	parentTagNode := (*TplNode)(nil)
 	tagNodeStack := make([]*TplNode, 0)
 	nodeArr := make([]*TplNode, 0)
+	errs := make([]*DdlError, 0)
 	isReadingTag := false
 	text := ""
+	textPos := 0
 	pos := 0
 
+	// turn the text read so far into a text node
+	flushText := func(parent *TplNode) {
+		if parent != nil && trim(text) != "" {
+			parent.Children = append(parent.Children, &TplNode{
+				Type:   TplNodeText,
+				Text:   html.UnescapeString(trim(text)),
+				Pos:    textPos,
+				Parent: parent,
+				Idx:    len(parent.Children),
+			})
+		}
+		text = ""
+	}
+
+	// record an error, and tell whether to stop parsing
+	fail := func(err error, errPos int) bool {
+		derr, ok := err.(*DdlError)
+		if !ok {
+			derr = WrapDdlError(tpl, errPos, err)
+		}
+		derr.SetDdl(tpl)
+		errs = append(errs, derr)
+		return !recoverable
+	}
+
 	for {
 		if pos >= len(tpl) {
 			break
@@ -308,8 +520,39 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 
 		left := tpl[pos:]
 
-		// <tag
-		if matches := tplPattern.openingTagBegin.FindStringSubmatch(left); !isReadingTag && len(matches) > 0 {
+		// <!-- comment -->
+		if !isReadingTag && strings.HasPrefix(left, "<!--") {
+			end := strings.Index(left[4:], "-->")
+			if end == -1 {
+				if fail(NewDdlError(tpl, pos, "tpl.unterminatedComment"), pos) {
+					return nodeArr, errs
+				}
+				pos = len(tpl)
+				continue
+			}
+			if keepComments {
+				flushText(parentTagNode)
+				commentNode := &TplNode{
+					Type:   TplNodeComment,
+					Text:   left[4 : end+4],
+					Pos:    pos,
+					Parent: parentTagNode,
+				}
+				if parentTagNode != nil {
+					commentNode.Idx = len(parentTagNode.Children)
+					parentTagNode.Children = append(parentTagNode.Children, commentNode)
+				} else {
+					nodeArr = append(nodeArr, commentNode)
+				}
+			}
+			pos += end + 7
+
+			// <tag
+		} else if matches := tplPattern.openingTagBegin.FindStringSubmatch(left); !isReadingTag && len(matches) > 0 {
+			flushText(parentTagNode)
+			if parentTagNode != nil {
+				nodeIdx = len(parentTagNode.Children)
+			}
 			name := matches[1]
 			tagNode := TplNode{
 				Type:    TplNodeTag,
@@ -321,17 +564,6 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 			tagNodeStack = append(tagNodeStack, &tagNode)
 			isReadingTag = true
 			if parentTagNode != nil {
-				if trim(text) != "" {
-					textNode := TplNode{
-						Type:   TplNodeText,
-						Text:   trim(text),
-						Pos:    pos - len(text),
-						Parent: parentTagNode,
-						Idx:    nodeIdx,
-					}
-					text = ""
-					parentTagNode.Children = append(parentTagNode.Children, &textNode)
-				}
 				parentTagNode.Children = append(parentTagNode.Children, &tagNode)
 			} else {
 				nodeArr = append(nodeArr, &tagNode)
@@ -343,6 +575,33 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 			isReadingTag = false
 			pos += 1
 
+			// the content of a raw text tag, up to its closing tag
+			if rawTextTags[curTagNode.TagName] {
+				end := strings.Index(tpl[pos:], "</"+curTagNode.TagName+">")
+				if end == -1 {
+					end = len(tpl) - pos
+				}
+				content := tpl[pos : pos+end]
+				contentPos := pos
+				if curTagNode.TagName == "pre" {
+					// like html, a newline right after <pre> is ignored
+					if strings.HasPrefix(content, "\n") {
+						content = content[1:]
+						contentPos += 1
+					}
+					content = html.UnescapeString(content)
+				}
+				if trim(content) != "" {
+					curTagNode.Children = append(curTagNode.Children, &TplNode{
+						Type:   TplNodeText,
+						Text:   content,
+						Pos:    contentPos,
+						Parent: curTagNode,
+					})
+				}
+				pos += end
+			}
+
 			// />
 		} else if matches := tplPattern.selfClosingTagEnd.FindStringSubmatch(left); isReadingTag && len(matches) > 0 {
 			isReadingTag = false
@@ -353,23 +612,42 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 			// </tag>
 		} else if matches := tplPattern.closingTag.FindStringSubmatch(left); !isReadingTag && len(matches) > 0 {
 			if parentTagNode == nil {
-				return nodeArr, NewDdlError(tpl, pos, "tpl.missingOpeningTag")
+				if fail(NewDdlError(tpl, pos, "tpl.missingOpeningTag"), pos) {
+					return nodeArr, errs
+				}
+				pos += len(matches[0])
+				continue
 			}
 			tagName := matches[1]
 			if tagName != parentTagNode.TagName {
-				return nodeArr, NewDdlError(tpl, pos, "tpl.mismatchedTag")
-			}
-			if parentTagNode != nil && trim(text) != "" {
-				textNode := TplNode{
-					Type:   TplNodeText,
-					Text:   trim(text),
-					Pos:    pos - len(text),
-					Parent: parentTagNode,
-					Idx:    nodeIdx,
+				merr := NewDdlError(tpl, pos, "tpl.mismatchedTag")
+				merr.SetEnd(pos + len(matches[0]))
+				merr.AddNote(parentTagNode.Pos, "tpl.note.openingTag", parentTagNode.TagName)
+				if fail(merr, pos) {
+					return nodeArr, errs
 				}
-				text = ""
-				parentTagNode.Children = append(parentTagNode.Children, &textNode)
+
+				// if an outer tag is being closed, the tags inside it are missing their closing tags.
+				// otherwise, the closing tag is a stray one.
+				openIdx := -1
+				for idx := slen - 1; idx >= 0; idx-- {
+					if tagNodeStack[idx].TagName == tagName {
+						openIdx = idx
+						break
+					}
+				}
+				if openIdx == -1 {
+					pos += len(matches[0])
+					continue
+				}
+				for idx := slen - 1; idx > openIdx; idx-- {
+					fail(NewDdlError(tpl, tagNodeStack[idx].Pos, "tpl.missingClosingTag"), pos)
+				}
+				tagNodeStack = tagNodeStack[:openIdx+1]
+				slen = len(tagNodeStack)
+				parentTagNode = tagNodeStack[slen-1]
 			}
+			flushText(parentTagNode)
 			curTagNode = nil
 			tagNodeStack = tagNodeStack[:slen-1]
 			pos += len(matches[0])
@@ -381,7 +659,7 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 			beginPos := pos
 			complete := false
 			klen := len(attrKey)
-			if left[klen+1] == '"' {
+			if klen+1 < len(left) && left[klen+1] == '"' {
 				for idx := klen + 2; idx < len(left); idx++ {
 					if left[idx] == '"' && left[idx-1] != '\\' {
 						attrVal = strings.ReplaceAll(string(left[klen+2:idx]), "\\\"", "\"")
@@ -390,7 +668,7 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 						break
 					}
 				}
-			} else if left[klen+1] == '\'' {
+			} else if klen+1 < len(left) && left[klen+1] == '\'' {
 				for idx := klen + 2; idx < len(left); idx++ {
 					if left[idx] == '\'' && left[idx-1] != '\\' {
 						attrVal = strings.ReplaceAll(string(left[klen+2:idx]), "\\'", "'")
@@ -401,76 +679,92 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 				}
 			}
 			if !complete {
-				if left[klen+1] == '"' {
-					return nodeArr, NewDdlError(tpl, pos+klen+1, "tpl.mismatchedDoubleQuotationMark")
-				} else {
-					return nodeArr, NewDdlError(tpl, pos+klen+1, "tpl.mismatchedSingleQuotationMark")
+				etype := "tpl.mismatchedSingleQuotationMark"
+				if klen+1 < len(left) && left[klen+1] == '"' {
+					etype = "tpl.mismatchedDoubleQuotationMark"
 				}
+				if fail(NewDdlError(tpl, pos+klen+1, etype), pos) {
+					return nodeArr, errs
+				}
+
+				// give up the rest of the tag
+				end := strings.IndexByte(left, '>')
+				if end == -1 {
+					end = len(left) - 1
+				}
+				pos += end
+				continue
 			}
-			err := curTagNode.addAttr(beginPos, attrKey, attrVal)
+			err := curTagNode.addAttr(beginPos, beginPos+klen+2, attrKey, attrVal)
 			if err != nil {
-				if tpe, ok := err.(*DdlError); ok {
-					tpe.SetDdl(tpl)
+				if fail(err, beginPos) {
+					return nodeArr, errs
 				}
-				return nodeArr, err
 			}
 
 			// attritube without value,  like the "enabled" attribute in "<comp enabled>".
 		} else if matches := tplPattern.attrWithoutVal.FindStringSubmatch(left); isReadingTag && len(matches) > 0 {
 			attrKey := matches[1]
 			attrVal := ""
-			err := curTagNode.addAttr(pos, attrKey, attrVal)
+			err := curTagNode.addAttr(pos, pos+len(attrKey), attrKey, attrVal)
 			if err != nil {
 				if tpe, ok := err.(*DdlError); ok {
-					tpe.SetDdl(tpl)
 					tpe.SetPos(pos)
 				}
-				return nodeArr, err
+				if fail(err, pos) {
+					return nodeArr, errs
+				}
 			}
 			pos += len(matches[0])
 
 			// {{ ... }}
 		} else if !isReadingTag && strings.HasPrefix(left, "{{") {
-			if parentTagNode != nil && trim(text) != "" {
-				textNode := TplNode{
-					Type:   TplNodeText,
-					Text:   trim(text),
-					Pos:    pos,
-					Parent: parentTagNode,
-					Idx:    nodeIdx,
-				}
-				text = ""
-				parentTagNode.Children = append(parentTagNode.Children, &textNode)
+			flushText(parentTagNode)
+			if parentTagNode != nil {
+				nodeIdx = len(parentTagNode.Children)
 			}
 
 			inDoubleQuote := false
 			inSingleQuote := false
-			expStr := ""
-			for idx := 2; idx < len(left)-2; idx++ {
+			complete := false
+			for idx := 2; idx < len(left)-1; idx++ {
 				if left[idx] == '\'' && left[idx-1] != '\\' && !inDoubleQuote {
 					inSingleQuote = !inSingleQuote
 				} else if left[idx] == '"' && left[idx-1] != '\\' && !inSingleQuote {
 					inDoubleQuote = !inDoubleQuote
 				} else if !inSingleQuote && !inDoubleQuote && left[idx:idx+2] == "}}" {
-					expStr = left[2:idx]
-					exp, err := ParseExp(expStr)
+					complete = true
+					exp, err := ParseExp(left[2:idx])
 					if err != nil {
-						return nodeArr, err
-					}
-					expNode := TplNode{
-						Type:   TplNodeExp,
-						Exp:    exp,
-						Pos:    pos + 2,
-						Parent: parentTagNode,
-						Idx:    nodeIdx,
+						if fail(offsetExpError(err, pos, pos+2), pos) {
+							return nodeArr, errs
+						}
+					} else if parentTagNode != nil {
+						expNode := TplNode{
+							Type:   TplNodeExp,
+							Exp:    exp,
+							Pos:    pos + 2,
+							Parent: parentTagNode,
+							Idx:    nodeIdx,
+						}
+						parentTagNode.Children = append(parentTagNode.Children, &expNode)
 					}
-					parentTagNode.Children = append(parentTagNode.Children, &expNode)
 					pos += idx + 2
+					break
 				}
 			}
+			if !complete {
+				if fail(NewDdlError(tpl, pos, "tpl.mismatchedCurlyBrace"), pos) {
+					return nodeArr, errs
+				}
+				pos = len(tpl)
+			}
 
 			// text
 		} else if !isReadingTag && !strings.HasPrefix(left, "{{") {
+			if text == "" {
+				textPos = pos
+			}
 			text += string(left[0])
 			pos += 1
 
@@ -480,17 +774,31 @@ func parseTpl(tpl string) ([]*TplNode, error) {
 
 			// others
 		} else {
-			return nodeArr, NewDdlError(tpl, pos, "tpl.unexpectedToken")
+			if fail(NewDdlError(tpl, pos, "tpl.unexpectedToken"), pos) {
+				return nodeArr, errs
+			}
+
+			// treat the tag as finished
+			isReadingTag = false
+			if strings.HasPrefix(left, "<") {
+				continue
+			}
+			pos += 1
 		}
 	}
 
 	if isReadingTag && curTagNode != nil {
-		return nodeArr, NewDdlError(tpl, curTagNode.Pos, "tpl.incompleteTag")
+		if fail(NewDdlError(tpl, curTagNode.Pos, "tpl.incompleteTag"), pos) {
+			return nodeArr, errs
+		}
+		tagNodeStack = tagNodeStack[:len(tagNodeStack)-1]
 	}
 
-	if len(tagNodeStack) > 0 {
-		return nodeArr, NewDdlError(tpl, tagNodeStack[len(tagNodeStack)-1].Pos, "tpl.missingClosingTag")
+	for idx := len(tagNodeStack) - 1; idx >= 0; idx-- {
+		if fail(NewDdlError(tpl, tagNodeStack[idx].Pos, "tpl.missingClosingTag"), pos) {
+			return nodeArr, errs
+		}
 	}
 
-	return nodeArr, nil
+	return nodeArr, errs
 }
@@ -1,6 +1,7 @@
 package ddl
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -74,6 +75,111 @@ var (
 			str: `<div @click="open()" v-on:click="open()"></div>`,
 			err: "tpl.duplicateEventHandler",
 		},
+		{
+			str: `<div>{{ a`,
+			err: "tpl.mismatchedCurlyBrace",
+		},
+		{
+			str: `<div>{{a}}{{b}}</div>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "div",
+					Children: []*TplNode{
+						{
+							Type: TplNodeExp,
+							Exp: &Exp{
+								Type:     ExpVar,
+								Variable: "a",
+							},
+						},
+						{
+							Type: TplNodeExp,
+							Exp: &Exp{
+								Type:     ExpVar,
+								Variable: "b",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			str: `<div><!-- <p> {{ a }} -->hello &amp; &lt;world&gt;<!----></div>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "div",
+					Children: []*TplNode{
+						{
+							Type: TplNodeText,
+							Text: "hello & <world>",
+						},
+					},
+				},
+			},
+		},
+		{
+			str: `<div title="a &amp; b" :key="'&amp;'"></div>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "div",
+					Attrs: map[string]*TplAttr{
+						"title": {
+							Exp: &Exp{
+								Type: ExpStr,
+								Str:  "a & b",
+							},
+						},
+						"key": {
+							Exp: &Exp{
+								Type: ExpStr,
+								Str:  "&amp;",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			str: `<div><!-- a </div>`,
+			err: "tpl.unterminatedComment",
+		},
+		{
+			str: `<style>.a { color: red; } a < b {{ c }} </p></style>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "style",
+					Children: []*TplNode{
+						{
+							Type: TplNodeText,
+							Text: ".a { color: red; } a < b {{ c }} </p>",
+						},
+					},
+				},
+			},
+		},
+		{
+			str: "<pre>\n  a <b> &amp; {{ c }}\n</pre>",
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "pre",
+					Children: []*TplNode{
+						{
+							Type: TplNodeText,
+							Text: "  a <b> & {{ c }}\n",
+						},
+					},
+				},
+			},
+		},
+		{
+			str: `<pre>abc`,
+			err: "tpl.missingClosingTag",
+		},
 		{
 			str: `<div v-if="a" v-if="b"></div>`,
 			err: "tpl.duplicateDirective",
@@ -106,6 +212,26 @@ var (
 			str: `<div v-for="abc"></div>`,
 			err: "tpl.invalidVforDirective",
 		},
+		{
+			str: `<div v-for="(a, b, c, d) of items"></div>`,
+			err: "tpl.invalidVforDirective",
+		},
+		{
+			str: `<div v-show="a" v-show="b"></div>`,
+			err: "tpl.duplicateDirective",
+		},
+		{
+			str: `<div v-once v-once></div>`,
+			err: "tpl.duplicateDirective",
+		},
+		{
+			str: `<div v-memo="[a]" v-memo="[b]"></div>`,
+			err: "tpl.duplicateDirective",
+		},
+		{
+			str: `<div v-once v-memo="[a]"></div>`,
+			err: "tpl.conflictedDirective",
+		},
 		{
 			str: `<template def=""></div>`,
 			err: "tpl.invalidDefAttr",
@@ -127,6 +253,59 @@ var (
 				},
 			},
 		},
+		{
+			str: `<template v-tooltip:top.lazy.once="tip" v-focus></template>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "template",
+					Directives: map[string]*TplDirective{
+						"tooltip:top": {
+							Name:      "tooltip",
+							Arg:       "top",
+							Modifiers: []string{"lazy", "once"},
+							Exp: &Exp{
+								Type:     ExpVar,
+								Variable: "tip",
+							},
+						},
+						"focus": {
+							Name: "focus",
+						},
+					},
+				},
+			},
+		},
+		{
+			str: `<div v-tooltip.="tip"></div>`,
+			err: "tpl.invalidDirective",
+		},
+		{
+			str: `<div v-focus:input v-focus:input.lazy></div>`,
+			err: "tpl.duplicateDirective",
+		},
+		{
+			str: `<div v-focus v-focus:input v-focus:button></div>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "div",
+					Directives: map[string]*TplDirective{
+						"focus": {
+							Name: "focus",
+						},
+						"focus:input": {
+							Name: "focus",
+							Arg:  "input",
+						},
+						"focus:button": {
+							Name: "focus",
+							Arg:  "button",
+						},
+					},
+				},
+			},
+		},
 		{
 			str: `<template>    hello, world!  </template>`,
 			tpl: []*TplNode{
@@ -439,6 +618,101 @@ var (
 				},
 			},
 		},
+		{
+			str: `
+			<template>
+				<comp-a v-for="item of items"></comp-a>
+				<comp-b v-for="(item, idx, total) of 10" v-show="idx > 0"></comp-b>
+				<comp-c v-once></comp-c>
+				<comp-d v-for="row of rows" v-memo="[row.Version]"></comp-d>
+			</template>`,
+			tpl: []*TplNode{
+				{
+					Type:    TplNodeTag,
+					TagName: "template",
+					Idx:     0,
+					Children: []*TplNode{
+						{
+							Type:    TplNodeTag,
+							TagName: "comp-a",
+							Idx:     0,
+							For: &TplFor{
+								Val: "item",
+								Range: &Exp{
+									Type:     ExpVar,
+									Variable: "items",
+								},
+							},
+						},
+						{
+							Type:    TplNodeTag,
+							TagName: "comp-b",
+							Idx:     1,
+							For: &TplFor{
+								Val:   "item",
+								Idx:   "idx",
+								Total: "total",
+								Range: &Exp{
+									Type: ExpInt,
+									Int:  10,
+								},
+							},
+							Show: &TplAttr{
+								Exp: &Exp{
+									Type:     ExpCalc,
+									Operator: ">",
+									Left: &Exp{
+										Type:     ExpVar,
+										Variable: "idx",
+									},
+									Right: &Exp{
+										Type: ExpInt,
+										Int:  0,
+									},
+								},
+							},
+						},
+						{
+							Type:    TplNodeTag,
+							TagName: "comp-c",
+							Idx:     2,
+							Once:    &TplAttr{},
+						},
+						{
+							Type:    TplNodeTag,
+							TagName: "comp-d",
+							Idx:     3,
+							For: &TplFor{
+								Val: "row",
+								Range: &Exp{
+									Type:     ExpVar,
+									Variable: "rows",
+								},
+							},
+							Memo: &TplAttr{
+								Exp: &Exp{
+									Type: ExpArray,
+									Array: []*Exp{
+										{
+											Type:     ExpCalc,
+											Operator: ".",
+											Left: &Exp{
+												Type:     ExpVar,
+												Variable: "row",
+											},
+											Right: &Exp{
+												Type: ExpStr,
+												Str:  "Version",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			str: `<template def="test()"></template>`,
 			tpl: []*TplNode{
@@ -520,8 +794,19 @@ var (
 	}
 )
 
+func isTplDirectiveEqual(a *TplDirective, b *TplDirective) bool {
+	if a.Name != b.Name || a.Arg != b.Arg || strings.Join(a.Modifiers, ".") != strings.Join(b.Modifiers, ".") {
+		return false
+	}
+	if a.Exp == nil || b.Exp == nil {
+		return a.Exp == b.Exp
+	}
+
+	return a.Exp.Equal(b.Exp)
+}
+
 func isTplForEqual(a TplFor, b TplFor) bool {
-	return a.Idx == b.Idx && a.Val == b.Val && (&a).Range.Equal(b.Range)
+	return a.Idx == b.Idx && a.Val == b.Val && a.Total == b.Total && (&a).Range.Equal(b.Range)
 }
 
 func isTplEqual(a []*TplNode, b []*TplNode) bool {
@@ -537,6 +822,10 @@ func isTplEqual(a []*TplNode, b []*TplNode) bool {
 			return false
 		}
 
+		if node1.Type != TplNodeTag && node1.Type != TplNodeExp && node1.Text != node2.Text {
+			return false
+		}
+
 		if node1.Type == TplNodeTag {
 			if node1.TagName != node2.TagName {
 				return false
@@ -570,12 +859,38 @@ func isTplEqual(a []*TplNode, b []*TplNode) bool {
 				return false
 			}
 
+			if (node1.Show != nil && node2.Show == nil) ||
+				(node1.Show == nil && node2.Show != nil) ||
+				(node1.Show != nil && node2.Show != nil && !node1.Show.Exp.Equal(node2.Show.Exp)) {
+				return false
+			}
+
+			if (node1.Once != nil && node2.Once == nil) ||
+				(node1.Once == nil && node2.Once != nil) {
+				return false
+			}
+
+			if (node1.Memo != nil && node2.Memo == nil) ||
+				(node1.Memo == nil && node2.Memo != nil) ||
+				(node1.Memo != nil && node2.Memo != nil && !node1.Memo.Exp.Equal(node2.Memo.Exp)) {
+				return false
+			}
+
 			if (node1.For != nil && node2.For == nil) ||
 				(node1.For == nil && node2.For != nil) ||
 				(node1.For != nil && node2.For != nil && !isTplForEqual(*node1.For, *node2.For)) {
 				return false
 			}
 
+			if len(node1.Directives) != len(node2.Directives) {
+				return false
+			}
+			for name, directive := range node1.Directives {
+				if other, ok := node2.Directives[name]; !ok || !isTplDirectiveEqual(directive, other) {
+					return false
+				}
+			}
+
 			if (node1.Events == nil && node2.Events != nil) ||
 				(node1.Events != nil && node2.Events == nil) ||
 				(node1.Events == nil && node2.Events == nil && len(node1.Events) != len(node2.Events)) {
@@ -642,3 +957,43 @@ func TestParseTpl(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTplWithComments(t *testing.T) {
+	str := "<!-- top --><div>a<!-- inner -->b</div>"
+	nodes, errs := ParseTplWithComments(str)
+	if len(errs) > 0 {
+		t.Fatal(errs[0])
+	}
+
+	expect := []*TplNode{
+		{
+			Type: TplNodeComment,
+			Text: " top ",
+		},
+		{
+			Type:    TplNodeTag,
+			TagName: "div",
+			Children: []*TplNode{
+				{
+					Type: TplNodeText,
+					Text: "a",
+				},
+				{
+					Type: TplNodeComment,
+					Text: " inner ",
+				},
+				{
+					Type: TplNodeText,
+					Text: "b",
+				},
+			},
+		},
+	}
+	if !isTplEqual(nodes, expect) {
+		spew.Dump(nodes)
+		t.Fatal("the comments are not kept as expected")
+	}
+	if nodes[1].Children[1].Pos != strings.Index(str, "<!-- inner") || nodes[1].Children[2].Idx != 2 {
+		t.Fatal("the comment node is not positioned as expected")
+	}
+}
@@ -0,0 +1,190 @@
+package rview
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/TinyWisp/rview/comp"
+	"github.com/TinyWisp/rview/ddl"
+	"github.com/TinyWisp/rview/tperr"
+)
+
+// a custom directive, like v-tooltip="Tip", v-shortcut:save.ctrl="'s'" or v-permission="'admin'".
+// Bind is called when a node with the directive is rendered for the first time, Update when it is rendered again,
+// and Unbind when it is gone. any of the hooks can be nil.
+type Directive struct {
+	Bind   func(binding *DirectiveBinding) error
+	Update func(binding *DirectiveBinding) error
+	Unbind func(binding *DirectiveBinding) error
+}
+
+// what the hooks of a directive get.
+// Value is nil if the directive has no value, and OldValue is the value of the last render, which is only set for Update.
+type DirectiveBinding struct {
+	Comp      comp.Component
+	Name      string
+	Value     interface{}
+	OldValue  interface{}
+	Arg       string
+	Modifiers map[string]bool
+}
+
+// the directives available to all the pages
+var (
+	globalDirectiveMap   = map[string]*Directive{}
+	globalDirectiveMutex sync.RWMutex
+)
+
+// the names handled by the template itself, which can't be used by a custom directive
+var builtinDirectives = map[string]bool{
+	"if":      true,
+	"else-if": true,
+	"else":    true,
+	"for":     true,
+	"show":    true,
+	"once":    true,
+	"memo":    true,
+	"bind":    true,
+	"on":      true,
+}
+
+// register a directive for all the pages, used like v-tooltip="Tip" if the name is "tooltip".
+// a directive of a page, declared in the Directives field of its definition, overrides a global one with the same name.
+func RegisterDirective(name string, directive *Directive) error {
+	if err := checkDirective(name, directive); err != nil {
+		return err
+	}
+
+	globalDirectiveMutex.Lock()
+	defer globalDirectiveMutex.Unlock()
+	globalDirectiveMap[name] = directive
+
+	return nil
+}
+
+func UnregisterDirective(name string) {
+	globalDirectiveMutex.Lock()
+	defer globalDirectiveMutex.Unlock()
+	delete(globalDirectiveMap, name)
+}
+
+func getGlobalDirective(name string) *Directive {
+	globalDirectiveMutex.RLock()
+	defer globalDirectiveMutex.RUnlock()
+
+	return globalDirectiveMap[name]
+}
+
+func checkDirective(name string, directive *Directive) error {
+	if directive == nil || builtinDirectives[name] {
+		return tperr.NewTypedError("page.invalidDirective", name)
+	}
+
+	return nil
+}
+
+func (p *Page) getDirective(name string) *Directive {
+	if directive, ok := p.directives[name]; ok {
+		return directive
+	}
+
+	return getGlobalDirective(name)
+}
+
+// call Bind, or Update if the node was rendered with the directive before, for each custom directive of a node, in the order they are written.
+// the watchers made by the hooks are collected by the scope of the node, which is stopped after Unbind.
+func (p *Page) bindDirectives(node *ComponentNode, tplNode *ddl.TplNode, component comp.Component) error {
+	if len(tplNode.Directives) == 0 {
+		return nil
+	}
+
+	tplDirectives := make([]*ddl.TplDirective, 0, len(tplNode.Directives))
+	for _, tplDirective := range tplNode.Directives {
+		tplDirectives = append(tplDirectives, tplDirective)
+	}
+	sort.Slice(tplDirectives, func(i int, j int) bool {
+		return tplDirectives[i].Pos < tplDirectives[j].Pos
+	})
+
+	getVariable := func(name string) (interface{}, error) {
+		return p.getVarForNode(node, name)
+	}
+
+	prevNode := p.prevNodes[node.Key]
+	node.Directives = map[string]*DirectiveBinding{}
+	for _, tplDirective := range tplDirectives {
+		directive := p.getDirective(tplDirective.Name)
+		if directive == nil {
+			return ddl.NewDdlError(p.Tpl, tplDirective.Pos, "page.directiveNotFound", tplDirective.Name)
+		}
+
+		binding := &DirectiveBinding{
+			Comp:      component,
+			Name:      tplDirective.Name,
+			Arg:       tplDirective.Arg,
+			Modifiers: map[string]bool{},
+		}
+		for _, modifier := range tplDirective.Modifiers {
+			binding.Modifiers[modifier] = true
+		}
+		if tplDirective.Exp != nil {
+			res, err := p.calcTplExp(tplDirective.Exp, tplDirective.ValPos, getVariable)
+			if err != nil {
+				return err
+			}
+			binding.Value = expToValue(res)
+		}
+		node.Directives[tplDirective.Key()] = binding
+
+		hook := directive.Bind
+		if prevNode != nil && prevNode.Comp == component {
+			if prevBinding, ok := prevNode.Directives[tplDirective.Key()]; ok {
+				binding.OldValue = prevBinding.Value
+				hook = directive.Update
+			}
+		}
+		if hook == nil {
+			continue
+		}
+
+		// the watchers made by the hooks live as long as the component
+		err := error(nil)
+		node.Scope.Run(func() {
+			err = hook(binding)
+		})
+		if err != nil {
+			return ddl.WrapDdlError(p.Tpl, tplDirective.Pos, err)
+		}
+	}
+
+	return nil
+}
+
+// call Unbind for the directives of the nodes which are gone after a render, those of a node in the order of their keys
+func (p *Page) unbindDirectives() error {
+	for key, prevNode := range p.prevNodes {
+		node := p.nodes[key]
+		directiveKeys := make([]string, 0, len(prevNode.Directives))
+		for directiveKey := range prevNode.Directives {
+			directiveKeys = append(directiveKeys, directiveKey)
+		}
+		sort.Strings(directiveKeys)
+
+		for _, directiveKey := range directiveKeys {
+			binding := prevNode.Directives[directiveKey]
+			if node != nil && node.Comp == binding.Comp && node.Directives[directiveKey] != nil {
+				continue
+			}
+
+			directive := p.getDirective(binding.Name)
+			if directive == nil || directive.Unbind == nil {
+				continue
+			}
+			if err := directive.Unbind(binding); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
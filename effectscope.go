@@ -0,0 +1,164 @@
+package rview
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// a group of watchers which are stopped together.
+// the watchers made by Watch, WatchSources, WatchSources2, WatchSources3, WatchRef, WatchRefs, RunAndWatch, RunReactively and Computed inside Run are collected by the scope,
+// and so are the scopes created inside Run, so that Stop tears them all down.
+// the stop func returned for a watcher still works, which also drops the watcher from the scope.
+type EffectScope struct {
+	mutex    sync.Mutex
+	parent   *EffectScope
+	effects  map[uint64]func()
+	children map[*EffectScope]bool
+	effectID uint64
+	stopped  bool
+}
+
+// the scopes running on each goroutine, the innermost last
+var (
+	activeScopes      = map[uint64][]*EffectScope{}
+	activeScopesMutex sync.Mutex
+	activeScopesDepth atomic.Int64
+)
+
+// create a scope, which is a child of the scope running on the current goroutine, if any
+func NewEffectScope() *EffectScope {
+	return newEffectScope(activeScope())
+}
+
+func newEffectScope(parent *EffectScope) *EffectScope {
+	s := &EffectScope{
+		parent: parent,
+	}
+
+	if parent != nil {
+		parent.mutex.Lock()
+		stopped := parent.stopped
+		if !stopped {
+			if parent.children == nil {
+				parent.children = map[*EffectScope]bool{}
+			}
+			parent.children[s] = true
+		}
+		parent.mutex.Unlock()
+
+		if stopped {
+			s.Stop()
+		}
+	}
+
+	return s
+}
+
+// the innermost scope running on the current goroutine
+func activeScope() *EffectScope {
+	if activeScopesDepth.Load() == 0 {
+		return nil
+	}
+
+	gid := goroutineID()
+
+	activeScopesMutex.Lock()
+	defer activeScopesMutex.Unlock()
+	scopes := activeScopes[gid]
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	return scopes[len(scopes)-1]
+}
+
+// run fn, collecting the watchers and the scopes created in it.
+// those created in a stopped scope are stopped at once.
+func (s *EffectScope) Run(fn func()) {
+	gid := goroutineID()
+
+	activeScopesMutex.Lock()
+	activeScopes[gid] = append(activeScopes[gid], s)
+	activeScopesDepth.Add(1)
+	activeScopesMutex.Unlock()
+
+	defer func() {
+		activeScopesMutex.Lock()
+		scopes := activeScopes[gid]
+		if len(scopes) == 1 {
+			delete(activeScopes, gid)
+		} else {
+			activeScopes[gid] = scopes[:len(scopes)-1]
+		}
+		activeScopesDepth.Add(-1)
+		activeScopesMutex.Unlock()
+	}()
+
+	fn()
+}
+
+// stop all the watchers and the scopes collected by the scope, which collects nothing from then on
+func (s *EffectScope) Stop() {
+	s.mutex.Lock()
+	if s.stopped {
+		s.mutex.Unlock()
+		return
+	}
+	s.stopped = true
+	effects, children := s.effects, s.children
+	s.effects, s.children = nil, nil
+	s.mutex.Unlock()
+
+	for _, stop := range effects {
+		stop()
+	}
+	for child := range children {
+		child.Stop()
+	}
+
+	if s.parent != nil {
+		s.parent.mutex.Lock()
+		delete(s.parent.children, s)
+		s.parent.mutex.Unlock()
+	}
+}
+
+func (s *EffectScope) isStopped() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stopped
+}
+
+// collect a stop func, and return the one which drops it from the scope as well
+func (s *EffectScope) add(stop func()) func() {
+	s.mutex.Lock()
+	if s.stopped {
+		s.mutex.Unlock()
+		stop()
+		return stop
+	}
+	if s.effects == nil {
+		s.effects = map[uint64]func(){}
+	}
+	s.effectID += 1
+	id := s.effectID
+	s.effects[id] = stop
+	s.mutex.Unlock()
+
+	return func() {
+		s.mutex.Lock()
+		delete(s.effects, id)
+		s.mutex.Unlock()
+		stop()
+	}
+}
+
+// collect the stop func of a watcher in the scope running on the current goroutine, if any
+func recordEffect(stop func()) func() {
+	s := activeScope()
+	if s == nil {
+		return stop
+	}
+
+	return s.add(stop)
+}
@@ -0,0 +1,65 @@
+package rview
+
+import (
+	"testing"
+)
+
+func TestEffectScope(t *testing.T) {
+	a := NewRef(1)
+	runs := map[string]int{}
+
+	scope := NewEffectScope()
+	child := (*EffectScope)(nil)
+	stopWatch := func() {}
+	double := (*Ref[int])(nil)
+	scope.Run(func() {
+		RunReactively(func() { a.Get(); runs["reactively"] += 1 })
+		RunAndWatch(func() { a.Get() }, func(watcher *Watcher) { runs["runAndWatch"] += 1 })
+		WatchRefs([]Watchable{a}, func() { runs["refs"] += 1 }, false)
+		stopWatch = WatchRef(a, func(newVal int, oldVal int) { runs["ref"] += 1 }, false)
+		double = Computed(func() int { return a.Get() * 2 })
+
+		// a scope created inside Run is a child of the scope
+		child = NewEffectScope()
+		child.Run(func() {
+			RunReactively(func() { a.Get(); runs["child"] += 1 })
+		})
+	})
+	if double.Get() != 2 {
+		t.Fatalf("the Computed is expected to work in the scope, got: %d", double.Get())
+	}
+
+	// a watcher stopped by itself is dropped from the scope
+	stopWatch()
+	if len(scope.effects) != 4 {
+		t.Fatalf("the scope is expected to hold 4 watchers, got: %d", len(scope.effects))
+	}
+
+	a.Set(2)
+	expect := map[string]int{"reactively": 2, "runAndWatch": 1, "refs": 1, "ref": 0, "child": 2}
+	for name, count := range expect {
+		if runs[name] != count {
+			t.Fatalf("the watcher %s is expected to run %d times, got: %d", name, count, runs[name])
+		}
+	}
+
+	scope.Stop()
+	a.Set(3)
+	for name, count := range expect {
+		if runs[name] != count {
+			t.Fatalf("the watcher %s is expected to be stopped, got: %d runs", name, runs[name])
+		}
+	}
+	if double.Get() != 2 || len(a.watchers) != 0 || !child.isStopped() {
+		t.Fatalf("all the watchers are expected to be stopped, got: %d watchers", len(a.watchers))
+	}
+
+	// a watcher made in a stopped scope is stopped at once
+	scope.Run(func() {
+		RunReactively(func() { a.Get(); runs["stopped"] += 1 })
+	})
+	a.Set(4)
+	if runs["stopped"] != 1 || len(a.watchers) != 0 {
+		t.Fatalf("the watcher is expected to be stopped at once, got: %d runs", runs["stopped"])
+	}
+}
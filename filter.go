@@ -0,0 +1,56 @@
+package rview
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/TinyWisp/rview/tperr"
+)
+
+// a VarGetter is asked for a filter by the filter's name with this prefix.
+// as it can never be the name of a variable, filters and variables don't collide.
+const FilterVarPrefix = "|"
+
+// the filters available to all the pages
+var (
+	globalFilterMap   = map[string]interface{}{}
+	globalFilterMutex sync.RWMutex
+)
+
+// register a filter for all the pages, used like {{ Price | currency('USD') }}.
+// a filter is a function receiving the value before '|' as its first argument, followed by the arguments in the parentheses.
+// a filter of a page, declared in the Filters field of its definition, overrides a global one with the same name.
+func RegisterFilter(name string, filter interface{}) error {
+	if err := checkFilter(name, filter); err != nil {
+		return err
+	}
+
+	globalFilterMutex.Lock()
+	defer globalFilterMutex.Unlock()
+	globalFilterMap[name] = filter
+
+	return nil
+}
+
+func UnregisterFilter(name string) {
+	globalFilterMutex.Lock()
+	defer globalFilterMutex.Unlock()
+	delete(globalFilterMap, name)
+}
+
+func getGlobalFilter(name string) interface{} {
+	globalFilterMutex.RLock()
+	defer globalFilterMutex.RUnlock()
+
+	return globalFilterMap[name]
+}
+
+// a filter must be a function with at least one parameter and one result
+func checkFilter(name string, filter interface{}) error {
+	filterType := reflect.TypeOf(filter)
+	if filterType == nil || filterType.Kind() != reflect.Func || filterType.NumIn() == 0 || filterType.NumOut() == 0 {
+		return tperr.NewTypedError("calc.invalidFilter", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package rview
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TinyWisp/rview/tperr"
+)
+
+// the functions available to the expressions of all the pages, like {{ len(Items) }}.
+// they are looked up after the fields, the variables and the methods, so a page can override any of them.
+var (
+	helperMap = map[string]interface{}{
+		"len":              helperLen,
+		"upper":            strings.ToUpper,
+		"lower":            strings.ToLower,
+		"trim":             strings.TrimSpace,
+		"join":             helperJoin,
+		"contains":         helperContains,
+		"sprintf":          fmt.Sprintf,
+		"min":              helperMin,
+		"max":              helperMax,
+		"round":            helperRound,
+		"truncate":         helperTruncate,
+		"now":              time.Now,
+		"formatDate":       helperFormatDate,
+		"humanizeBytes":    helperHumanizeBytes,
+		"humanizeDuration": helperHumanizeDuration,
+	}
+	helperMutex sync.RWMutex
+)
+
+// register a helper function for all the pages, or replace a built-in one
+func RegisterHelper(name string, helper interface{}) error {
+	helperType := reflect.TypeOf(helper)
+	if helperType == nil || helperType.Kind() != reflect.Func || helperType.NumOut() == 0 {
+		return tperr.NewTypedError("calc.invalidHelper", name)
+	}
+
+	helperMutex.Lock()
+	defer helperMutex.Unlock()
+	helperMap[name] = helper
+
+	return nil
+}
+
+func UnregisterHelper(name string) {
+	helperMutex.Lock()
+	defer helperMutex.Unlock()
+	delete(helperMap, name)
+}
+
+func getHelper(name string) interface{} {
+	helperMutex.RLock()
+	defer helperMutex.RUnlock()
+
+	return helperMap[name]
+}
+
+func unsupportedArgument(helper string, arg interface{}) error {
+	return tperr.NewTypedError("calc.unsupportedArgument", helper, fmt.Sprintf("%T", arg))
+}
+
+// the number of the elements of a slice, an array or a map, or the number of the characters of a string
+func helperLen(v interface{}) (int, error) {
+	if v == nil {
+		return 0, nil
+	}
+
+	if str, ok := v.(string); ok {
+		return utf8.RuneCountInString(str), nil
+	}
+
+	// a ReactiveSlice or a ReactiveMap, whose length is watched
+	if iterable, ok := v.(reactiveIterable); ok {
+		return iterable.Len(), nil
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan, reflect.String:
+		return val.Len(), nil
+
+	case reflect.Pointer:
+		if val.Elem().Kind() == reflect.Array {
+			return val.Elem().Len(), nil
+		}
+	}
+
+	return 0, unsupportedArgument("len", v)
+}
+
+// join the elements of a slice or an array, whatever their types are
+func helperJoin(v interface{}, sep ...string) (string, error) {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return "", unsupportedArgument("join", v)
+	}
+
+	strs := make([]string, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		strs = append(strs, fmt.Sprint(val.Index(i).Interface()))
+	}
+
+	return strings.Join(strs, strings.Join(sep, "")), nil
+}
+
+// whether a string contains a substring, a slice or an array contains an element, or a map contains a key
+func helperContains(container interface{}, item interface{}) (bool, error) {
+	if str, ok := container.(string); ok {
+		sub, ok := item.(string)
+		if !ok {
+			return false, unsupportedArgument("contains", item)
+		}
+		return strings.Contains(str, sub), nil
+	}
+
+	val := reflect.ValueOf(container)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if looselyEqual(val.Index(i).Interface(), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case reflect.Map:
+		for _, key := range val.MapKeys() {
+			if looselyEqual(key.Interface(), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, unsupportedArgument("contains", container)
+}
+
+// compare two values, regarding the numbers of different types, like int8(1) and int64(1), as equal
+func looselyEqual(a interface{}, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+
+	return 0, false
+}
+
+// the smallest, or the largest, of some numbers or some strings.
+// the value is returned as it is, so min(1, 2) is an int, while min(1, 2.5) is a float.
+func helperMin(first interface{}, rest ...interface{}) (interface{}, error) {
+	return pickExtreme("min", first, rest, -1)
+}
+
+func helperMax(first interface{}, rest ...interface{}) (interface{}, error) {
+	return pickExtreme("max", first, rest, 1)
+}
+
+func pickExtreme(helper string, first interface{}, rest []interface{}, sign int) (interface{}, error) {
+	res := first
+	for _, v := range rest {
+		cmp := 0
+		if rf, ok := toFloat(res); ok {
+			vf, ok := toFloat(v)
+			if !ok {
+				return nil, unsupportedArgument(helper, v)
+			}
+			if vf > rf {
+				cmp = 1
+			} else if vf < rf {
+				cmp = -1
+			}
+		} else if rs, ok := res.(string); ok {
+			vs, ok := v.(string)
+			if !ok {
+				return nil, unsupportedArgument(helper, v)
+			}
+			cmp = strings.Compare(vs, rs)
+		} else {
+			return nil, unsupportedArgument(helper, res)
+		}
+
+		if cmp == sign {
+			res = v
+		}
+	}
+
+	if _, ok := toFloat(res); !ok {
+		if _, ok := res.(string); !ok {
+			return nil, unsupportedArgument(helper, res)
+		}
+	}
+
+	return res, nil
+}
+
+// round a number half away from zero, to some decimal places if given
+func helperRound(x float64, places ...int) float64 {
+	if len(places) == 0 || places[0] == 0 {
+		return math.Round(x)
+	}
+
+	scale := math.Pow(10, float64(places[0]))
+	return math.Round(x*scale) / scale
+}
+
+// cut a string to at most length characters, ending it with the suffix, which is "..." by default, if it is cut
+func helperTruncate(str string, length int, suffix ...string) string {
+	if utf8.RuneCountInString(str) <= length {
+		return str
+	}
+
+	tail := "..."
+	if len(suffix) > 0 {
+		tail = strings.Join(suffix, "")
+	}
+
+	runes := []rune(str)
+	keep := length - utf8.RuneCountInString(tail)
+	if keep < 0 {
+		keep = 0
+	}
+
+	return string(runes[:keep]) + tail
+}
+
+// format a time with a go layout, which is "2006-01-02 15:04:05" by default
+func helperFormatDate(t time.Time, layout ...string) string {
+	if len(layout) == 0 {
+		return t.Format("2006-01-02 15:04:05")
+	}
+
+	return t.Format(layout[0])
+}
+
+// a size in bytes for humans, like "1.5 KB"
+func helperHumanizeBytes(size float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+	idx := 0
+	for math.Abs(size) >= 1024 && idx < len(units)-1 {
+		size /= 1024
+		idx += 1
+	}
+
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(math.Round(size*10)/10, 'f', -1, 64), units[idx])
+}
+
+// a duration for humans, with its two largest units, like "1h 5m" or "3m 20s"
+func helperHumanizeDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	if d < time.Second {
+		return fmt.Sprintf("%s%dms", sign, d.Milliseconds())
+	}
+
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+
+	parts := []string{}
+	for _, unit := range units {
+		if len(parts) == 2 {
+			break
+		}
+		count := d / unit.size
+		d -= count * unit.size
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", count, unit.name))
+		} else if len(parts) > 0 {
+			break
+		}
+	}
+
+	return sign + strings.Join(parts, " ")
+}
@@ -0,0 +1,91 @@
+package rview
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TinyWisp/rview/ddl"
+	"github.com/TinyWisp/rview/tperr"
+)
+
+func TestHelper(t *testing.T) {
+	page := Page{
+		def: testDef,
+	}
+	getVariable := func(name string) (interface{}, error) {
+		return page.getVarForNode(nil, name)
+	}
+
+	if err := RegisterHelper("newYear", func() time.Time { return time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC) }); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterHelper("newYear")
+
+	// a page can override a helper with its own field
+	if err := RegisterHelper("FuncPlus", func(a int, b int) int { return 0 }); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterHelper("FuncPlus")
+
+	if err := RegisterHelper("bad", 1); !tperr.IsErrorType(err, "calc.invalidHelper") {
+		t.Fatalf("a helper must be a function, got: %v", err)
+	}
+
+	cases := []CalcExpCase{
+		{exp: `len(ArrStr)`, expect: `2`},
+		{exp: `len(MapStrStr)`, expect: `2`},
+		{exp: `len("你好")`, expect: `2`},
+		{exp: `len(nil)`, expect: `0`},
+		{exp: `len(3)`, err: `calc.unsupportedArgument`},
+		{exp: `upper(StringVarHello) + lower("WORLD")`, expect: `"HELLOworld"`},
+		{exp: `StringVarHello | upper`, expect: `"HELLO"`},
+		{exp: `join(ArrInt, ", ")`, expect: `"10, 11"`},
+		{exp: `contains(ArrInt, 11)`, expect: `true`},
+		{exp: `contains(ArrStr, "nope")`, expect: `false`},
+		{exp: `contains(MapStrStr, "hello")`, expect: `true`},
+		{exp: `contains(StringVarHello, "ell")`, expect: `true`},
+		{exp: `sprintf("%s-%d", StringVarHello, Int8Var2)`, expect: `"hello-2"`},
+		{exp: `min(3, Int8Var2, 5)`, expect: `2`},
+		{exp: `max(3, 4.5, Int8Var2)`, expect: `4.5`},
+		{exp: `max("a", "c", "b")`, expect: `"c"`},
+		{exp: `min(1, "a")`, err: `calc.unsupportedArgument`},
+		{exp: `round(2.5)`, expect: `3.0`},
+		{exp: `round(3.14159, 2)`, expect: `3.14`},
+		{exp: `truncate("hello world", 8)`, expect: `"hello..."`},
+		{exp: `truncate("hello", 8)`, expect: `"hello"`},
+		{exp: `truncate("你好世界", 3, "…")`, expect: `"你好…"`},
+		{exp: `formatDate(newYear())`, expect: `"2024-01-01 08:30:00"`},
+		{exp: `formatDate(newYear(), "Jan 2, 2006")`, expect: `"Jan 1, 2024"`},
+		{exp: `humanizeBytes(512)`, expect: `"512 B"`},
+		{exp: `humanizeBytes(1536)`, expect: `"1.5 KB"`},
+		{exp: `humanizeBytes(3 * 1024 * 1024 * 1024)`, expect: `"3 GB"`},
+		{exp: `humanizeDuration(250000000)`, expect: `"250ms"`},
+		{exp: `humanizeDuration(3725000000000)`, expect: `"1h 2m"`},
+		{exp: `humanizeDuration(86400000000000 + 5000000000)`, expect: `"1d"`},
+		{exp: `FuncPlus(1, 2)`, expect: `3`},
+	}
+
+	for _, testCase := range cases {
+		t.Log(testCase.exp)
+
+		exp, err := ddl.ParseExp(testCase.exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := CalcExp(exp, getVariable)
+		if testCase.err != "" {
+			if !tperr.IsErrorType(err, testCase.err) {
+				t.Fatalf("expect the error %s, got: %v", testCase.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect, _ := ddl.ParseExp(testCase.expect)
+		if !expect.Equal(res) {
+			t.Fatalf("expect: %s, got: %s", testCase.expect, res.ToString())
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/TinyWisp/rview/comp"
+	"github.com/TinyWisp/rview/ddl"
+)
+
+var (
+	analysisPattern = struct {
+		defTpl      *regexp.Regexp
+		tagName     *regexp.Regexp
+		openingTag  *regexp.Regexp
+		attrBefore  *regexp.Regexp
+		cssPropHead *regexp.Regexp
+	}{
+		defTpl:      regexp.MustCompile(`<template\s[^>]*?def\s*=\s*['"]([a-zA-Z0-9_\-]+)\(([^)]*)\)`),
+		tagName:     regexp.MustCompile(`^</?([a-zA-Z0-9\-]*)$`),
+		openingTag:  regexp.MustCompile(`^<([a-zA-Z0-9\-]+)`),
+		attrBefore:  regexp.MustCompile(`([a-zA-Z0-9\-_@:.]+)=$`),
+		cssPropHead: regexp.MustCompile(`[{;]\s*[a-zA-Z0-9_\-]*$`),
+	}
+
+	directiveNames = []string{"v-if", "v-else-if", "v-else", "v-show", "v-once", "v-memo", "v-for", "key", "ref"}
+)
+
+type contextKind int
+
+const (
+	contextNone contextKind = iota
+	contextTagName
+	contextAttrName
+	contextExp
+	contextCssProp
+)
+
+type cursorContext struct {
+	kind    contextKind
+	tagName string
+}
+
+type defTpl struct {
+	name   string
+	params string
+	begin  int
+	end    int
+}
+
+// implemented by the components that can list their props, like the ones built on comp.Base
+type propTyped interface {
+	GetPropTypes() map[string]reflect.Type
+}
+
+type Analyzer struct {
+	TagCompCreatorMap map[string]func() comp.Component
+	propTypeCache     map[string]map[string]reflect.Type
+}
+
+func NewAnalyzer(tagCompCreatorMap map[string]func() comp.Component) *Analyzer {
+	return &Analyzer{
+		TagCompCreatorMap: tagCompCreatorMap,
+		propTypeCache:     map[string]map[string]reflect.Type{},
+	}
+}
+
+// get the props of the component bound to a tag, or nil if the tag is not a known component
+func (a *Analyzer) propTypes(tagName string) map[string]reflect.Type {
+	if propTypes, ok := a.propTypeCache[tagName]; ok {
+		return propTypes
+	}
+
+	creator, ok := a.TagCompCreatorMap[tagName]
+	if !ok {
+		return nil
+	}
+
+	propTypes := map[string]reflect.Type{}
+	if typed, ok := creator().(propTyped); ok {
+		propTypes = typed.GetPropTypes()
+	}
+	a.propTypeCache[tagName] = propTypes
+
+	return propTypes
+}
+
+func (a *Analyzer) Diagnose(doc *Document) []Diagnostic {
+	diags := []Diagnostic{}
+
+	def, errs := ddl.ParseDdlWithRecovery(doc.Text)
+	for _, err := range errs {
+		diag := err.Diagnostic()
+		pos, end := err.GetPos(), err.GetPos()+1
+		if diag.Span != nil && diag.Span.End.Offset > diag.Span.Start.Offset {
+			pos, end = diag.Span.Start.Offset, diag.Span.End.Offset
+		}
+		related := []DiagnosticRelatedInformation{}
+		for _, note := range diag.Notes {
+			if note.Span == nil {
+				continue
+			}
+			related = append(related, DiagnosticRelatedInformation{
+				Location: Location{
+					URI:   doc.URI,
+					Range: doc.RangeOf(note.Span.Start.Offset, note.Span.End.Offset),
+				},
+				Message: note.Message,
+			})
+		}
+		diags = append(diags, Diagnostic{
+			Range:              doc.RangeOf(pos, end),
+			Severity:           SeverityError,
+			Code:               diag.Code,
+			Source:             "rview",
+			Message:            diag.Message,
+			RelatedInformation: related,
+		})
+	}
+
+	defTpls := map[string]bool{}
+	for name := range def.TplMap {
+		defTpls[name] = true
+	}
+
+	var walk func(node *ddl.TplNode, isRoot bool)
+	walk = func(node *ddl.TplNode, isRoot bool) {
+		if node.Type != ddl.TplNodeTag {
+			return
+		}
+
+		propTypes := a.propTypes(node.TagName)
+		if !isRoot && propTypes == nil && !defTpls[node.TagName] {
+			diags = append(diags, Diagnostic{
+				Range:    doc.RangeOf(node.Pos+1, node.Pos+1+len(node.TagName)),
+				Severity: SeverityWarning,
+				Code:     "page.compNotFound",
+				Source:   "rview",
+				Message:  fmt.Sprintf("unknown component: <%s>", node.TagName),
+			})
+		}
+
+		if !isRoot && len(propTypes) > 0 {
+			for prop, attr := range node.Attrs {
+				if prop == "key" || prop == "ref" {
+					continue
+				}
+				if _, ok := propTypes[prop]; ok {
+					continue
+				}
+				_, _, end := doc.WordAt(attr.Pos + 1)
+				diags = append(diags, Diagnostic{
+					Range:    doc.RangeOf(attr.Pos, end),
+					Severity: SeverityWarning,
+					Code:     "comp.SetProp.propNotAllowed",
+					Source:   "rview",
+					Message:  fmt.Sprintf("unknown property '%s' on <%s>", prop, node.TagName),
+				})
+			}
+		}
+
+		for _, child := range node.Children {
+			walk(child, false)
+		}
+	}
+	for _, root := range def.TplMap {
+		walk(root, true)
+	}
+
+	sort.SliceStable(diags, func(i int, j int) bool {
+		if diags[i].Range.Start.Line != diags[j].Range.Start.Line {
+			return diags[i].Range.Start.Line < diags[j].Range.Start.Line
+		}
+		return diags[i].Range.Start.Character < diags[j].Range.Start.Character
+	})
+
+	return diags
+}
+
+func (a *Analyzer) Complete(doc *Document, offset int, fields []DefField) []CompletionItem {
+	items := []CompletionItem{}
+	ctx := contextAt(doc.Text, offset)
+
+	switch ctx.kind {
+	case contextTagName:
+		tagNames := make([]string, 0, len(a.TagCompCreatorMap))
+		for tagName := range a.TagCompCreatorMap {
+			tagNames = append(tagNames, tagName)
+		}
+		sort.Strings(tagNames)
+		for _, tagName := range tagNames {
+			items = append(items, CompletionItem{
+				Label:  tagName,
+				Kind:   CompletionKindClass,
+				Detail: "component",
+			})
+		}
+		for _, tpl := range findDefTpls(doc.Text) {
+			items = append(items, CompletionItem{
+				Label:  tpl.name,
+				Kind:   CompletionKindClass,
+				Detail: fmt.Sprintf("template %s(%s)", tpl.name, tpl.params),
+			})
+		}
+
+	case contextAttrName:
+		propTypes := a.propTypes(ctx.tagName)
+		props := make([]string, 0, len(propTypes))
+		for prop := range propTypes {
+			props = append(props, prop)
+		}
+		sort.Strings(props)
+		for _, prop := range props {
+			items = append(items, CompletionItem{
+				Label:  prop,
+				Kind:   CompletionKindProperty,
+				Detail: propTypes[prop].String(),
+			})
+		}
+		for _, directive := range directiveNames {
+			items = append(items, CompletionItem{
+				Label: directive,
+				Kind:  CompletionKindKeyword,
+			})
+		}
+
+	case contextExp:
+		for _, field := range fields {
+			items = append(items, CompletionItem{
+				Label:  field.Name,
+				Kind:   CompletionKindField,
+				Detail: field.Type,
+			})
+		}
+
+	case contextCssProp:
+		for _, prop := range ddl.CssPropNames() {
+			items = append(items, CompletionItem{
+				Label:  prop,
+				Kind:   CompletionKindProperty,
+				Detail: describeCssRule(prop),
+			})
+		}
+	}
+
+	return items
+}
+
+func (a *Analyzer) Hover(doc *Document, offset int, fields []DefField) *Hover {
+	word, begin, end := doc.WordAt(offset)
+	if word == "" {
+		return nil
+	}
+
+	content := ""
+	ctx := contextAt(doc.Text, begin)
+	switch ctx.kind {
+	case contextTagName:
+		if propTypes := a.propTypes(word); propTypes != nil {
+			props := make([]string, 0, len(propTypes))
+			for prop, ptype := range propTypes {
+				props = append(props, fmt.Sprintf("- `%s`: `%s`", prop, ptype.String()))
+			}
+			sort.Strings(props)
+			content = fmt.Sprintf("**<%s>** component\n\n%s", word, strings.Join(props, "\n"))
+		}
+		for _, tpl := range findDefTpls(doc.Text) {
+			if tpl.name == word {
+				content = fmt.Sprintf("**%s**(%s) template", tpl.name, tpl.params)
+			}
+		}
+
+	case contextAttrName:
+		if ptype, ok := a.propTypes(ctx.tagName)[word]; ok {
+			content = fmt.Sprintf("`%s`: `%s`", word, ptype.String())
+		}
+
+	case contextExp:
+		for _, field := range fields {
+			if field.Name == word {
+				content = fmt.Sprintf("`%s %s`", field.Name, field.Type)
+				if field.Doc != "" {
+					content += "\n\n" + field.Doc
+				}
+			}
+		}
+
+	case contextCssProp:
+		if _, ok := ddl.CssPropRule(word); ok {
+			content = fmt.Sprintf("`%s`: %s", word, describeCssRule(word))
+		}
+	}
+
+	if content == "" {
+		return nil
+	}
+
+	rng := doc.RangeOf(begin, end)
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: content,
+		},
+		Range: &rng,
+	}
+}
+
+// find the named template a tag refers to
+func (a *Analyzer) Definition(doc *Document, offset int) *Location {
+	word, begin, _ := doc.WordAt(offset)
+	if word == "" || contextAt(doc.Text, begin).kind != contextTagName {
+		return nil
+	}
+
+	for _, tpl := range findDefTpls(doc.Text) {
+		if tpl.name == word {
+			return &Location{
+				URI:   doc.URI,
+				Range: doc.RangeOf(tpl.begin, tpl.end),
+			}
+		}
+	}
+
+	return nil
+}
+
+func findDefTpls(text string) []defTpl {
+	tpls := []defTpl{}
+	for _, match := range analysisPattern.defTpl.FindAllStringSubmatchIndex(text, -1) {
+		tpls = append(tpls, defTpl{
+			name:   text[match[2]:match[3]],
+			params: text[match[4]:match[5]],
+			begin:  match[0],
+			end:    match[1],
+		})
+	}
+
+	return tpls
+}
+
+func describeCssRule(prop string) string {
+	rule, ok := ddl.CssPropRule(prop)
+	if !ok {
+		return ""
+	}
+
+	forms := []string{}
+	for _, form := range rule {
+		names := []string{}
+		for _, tokenType := range form {
+			names = append(names, ddl.CSSTokenTypeName[tokenType])
+		}
+		forms = append(forms, strings.Join(names, " "))
+	}
+
+	return strings.Join(forms, " | ")
+}
+
+// work out what is being edited at the offset by scanning the text before it
+func contextAt(text string, offset int) cursorContext {
+	const (
+		stateText = iota
+		stateTag
+		stateQuote
+		stateExp
+		stateCss
+		stateComment
+		statePre
+	)
+
+	state := stateText
+	tagBegin := 0
+	quoteBegin := 0
+	quote := byte(0)
+	cssBegin := 0
+	for pos := 0; pos < offset; pos++ {
+		ch := text[pos]
+		switch state {
+		case stateText:
+			if strings.HasPrefix(text[pos:], "<!--") {
+				state = stateComment
+				pos += 3
+			} else if ch == '<' && pos+1 < len(text) && (isLetter(text[pos+1]) || text[pos+1] == '/') {
+				state = stateTag
+				tagBegin = pos
+			} else if ch == '<' && pos+1 == offset {
+				state = stateTag
+				tagBegin = pos
+			} else if strings.HasPrefix(text[pos:], "{{") {
+				state = stateExp
+				pos += 1
+			}
+
+		case stateTag:
+			if ch == '"' || ch == '\'' {
+				state = stateQuote
+				quote = ch
+				quoteBegin = pos
+			} else if ch == '>' {
+				state = stateText
+				if strings.HasPrefix(text[tagBegin:], "<style") {
+					state = stateCss
+					cssBegin = pos + 1
+				} else if strings.HasPrefix(text[tagBegin:], "<pre") {
+					state = statePre
+				}
+			}
+
+		case stateQuote:
+			if ch == quote && text[pos-1] != '\\' {
+				state = stateTag
+			}
+
+		case stateExp:
+			if strings.HasPrefix(text[pos:], "}}") {
+				state = stateText
+				pos += 1
+			}
+
+		case stateCss:
+			if strings.HasPrefix(text[pos:], "</style") {
+				state = stateTag
+				tagBegin = pos
+			}
+
+		case stateComment:
+			if strings.HasPrefix(text[pos:], "-->") {
+				state = stateText
+				pos += 2
+			}
+
+		case statePre:
+			if strings.HasPrefix(text[pos:], "</pre") {
+				state = stateTag
+				tagBegin = pos
+			}
+		}
+	}
+
+	switch state {
+	case stateTag:
+		segment := text[tagBegin:offset]
+		if analysisPattern.tagName.MatchString(segment) {
+			return cursorContext{kind: contextTagName}
+		}
+		if matches := analysisPattern.openingTag.FindStringSubmatch(segment); len(matches) > 0 {
+			return cursorContext{kind: contextAttrName, tagName: matches[1]}
+		}
+
+	case stateQuote:
+		segment := text[tagBegin:quoteBegin]
+		if matches := analysisPattern.attrBefore.FindStringSubmatch(strings.TrimRight(segment, " \t")); len(matches) > 0 {
+			attr := matches[1]
+			if strings.HasPrefix(attr, ":") || strings.HasPrefix(attr, "@") || strings.HasPrefix(attr, "v-") {
+				return cursorContext{kind: contextExp}
+			}
+		}
+
+	case stateExp:
+		return cursorContext{kind: contextExp}
+
+	case stateCss:
+		if analysisPattern.cssPropHead.MatchString(text[cssBegin:offset]) {
+			return cursorContext{kind: contextCssProp}
+		}
+	}
+
+	return cursorContext{kind: contextNone}
+}
+
+func isLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
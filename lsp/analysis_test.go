@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TinyWisp/rview"
+)
+
+type completeTestCase struct {
+	text    string
+	contain []string
+	exclude []string
+}
+
+var (
+	testFields = []DefField{
+		{Name: "Title", Type: "string"},
+		{Name: "Items", Type: "[]string"},
+	}
+
+	completeTestCases = []completeTestCase{
+		{
+			text:    `<template><|`,
+			contain: []string{"box", "flex", "textarea"},
+		},
+		{
+			text:    `<template><bo|`,
+			contain: []string{"box", "button"},
+		},
+		{
+			text:    `<template><box |`,
+			contain: []string{"title", "border", "v-if", "v-for"},
+			exclude: []string{"prop", "rect"},
+		},
+		{
+			text:    `<template><box :title="Ti|"`,
+			contain: []string{"Title", "Items"},
+		},
+		{
+			text:    `<template><box title="Ti|"`,
+			exclude: []string{"Title", "Items"},
+		},
+		{
+			text:    `<template><box v-if="3 > 1" :title="|"`,
+			contain: []string{"Title"},
+		},
+		{
+			text:    `<template><box>{{ Ti|`,
+			contain: []string{"Title"},
+		},
+		{
+			text:    `<template><box>{{ Title }} |`,
+			exclude: []string{"Title", "box"},
+		},
+		{
+			text:    "<style>\n.a {\n  mar|",
+			contain: []string{"margin", "margin-left", "background-color"},
+		},
+		{
+			text:    "<style>\n.a {\n  margin: 1|",
+			exclude: []string{"margin"},
+		},
+		{
+			text:    `<template><!-- <|`,
+			exclude: []string{"box"},
+		},
+		{
+			text:    `<template><!-- <box> --><|`,
+			contain: []string{"box"},
+		},
+		{
+			text:    `<template><pre><|`,
+			exclude: []string{"box"},
+		},
+		{
+			text:    "<template><link-item/></template><template def='link-item(a)'></template>\n<template><|",
+			contain: []string{"link-item"},
+		},
+	}
+)
+
+func hasLabel(items []CompletionItem, label string) bool {
+	for _, item := range items {
+		if item.Label == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestComplete(t *testing.T) {
+	analyzer := NewAnalyzer(rview.DefaultTagCompCreatorMap)
+	for _, testCase := range completeTestCases {
+		t.Log(testCase.text)
+
+		offset := strings.Index(testCase.text, "|")
+		doc := &Document{
+			URI:  "file:///tmp/test.rview",
+			Text: strings.Replace(testCase.text, "|", "", 1),
+		}
+		items := analyzer.Complete(doc, offset, testFields)
+		for _, label := range testCase.contain {
+			if !hasLabel(items, label) {
+				t.Fatalf("expected completion item not found: %s", label)
+			}
+		}
+		for _, label := range testCase.exclude {
+			if hasLabel(items, label) {
+				t.Fatalf("unexpected completion item: %s", label)
+			}
+		}
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	analyzer := NewAnalyzer(rview.DefaultTagCompCreatorMap)
+
+	doc := &Document{
+		Text: "<template>\n  <box>\n</template>",
+	}
+	diags := analyzer.Diagnose(doc)
+	if len(diags) != 2 {
+		t.Fatalf("2 diagnostics are expected, got: %v", diags)
+	}
+	if diags[0].Code != "tpl.missingClosingTag" || diags[0].Range.Start != (Position{Line: 1, Character: 2}) {
+		t.Fatalf("a missing closing tag is expected, got: %v", diags[0])
+	}
+	if diags[1].Code != "tpl.mismatchedTag" || diags[1].Severity != SeverityError || diags[1].Range.Start != (Position{Line: 2, Character: 0}) {
+		t.Fatalf("a mismatched tag is expected, got: %v", diags[1])
+	}
+
+	doc = &Document{
+		Text: "<template>\n  <box :title='1 +' />\n  <box v-if='(a' />\n</template>",
+	}
+	diags = analyzer.Diagnose(doc)
+	if len(diags) != 2 {
+		t.Fatalf("2 diagnostics are expected, got: %v", diags)
+	}
+	if diags[0].Code != "exp.incompleteExpression" || diags[0].Range.Start != (Position{Line: 1, Character: 17}) {
+		t.Fatalf("an incomplete expression is expected, got: %v", diags[0])
+	}
+	if diags[1].Code != "exp.mismatchedParenthesis" || diags[1].Range.Start != (Position{Line: 2, Character: 13}) {
+		t.Fatalf("a mismatched parenthesis is expected, got: %v", diags[1])
+	}
+
+	doc = &Document{
+		Text: "<template>\n  <flex>\n    <boxx/>\n    <box titl='a' />\n  </flex>\n</template>",
+	}
+	diags = analyzer.Diagnose(doc)
+	if len(diags) != 2 {
+		t.Fatalf("2 diagnostics are expected, got: %v", diags)
+	}
+	if diags[0].Code != "page.compNotFound" || diags[0].Range.Start != (Position{Line: 2, Character: 5}) {
+		t.Fatalf("an unknown component is expected, got: %v", diags[0])
+	}
+	if diags[1].Code != "comp.SetProp.propNotAllowed" || diags[1].Range.Start != (Position{Line: 3, Character: 9}) {
+		t.Fatalf("an unknown property is expected, got: %v", diags[1])
+	}
+}
+
+func TestHoverAndDefinition(t *testing.T) {
+	analyzer := NewAnalyzer(rview.DefaultTagCompCreatorMap)
+	doc := &Document{
+		URI:  "file:///tmp/test.rview",
+		Text: "<template>\n  <link-item :title='Title'/>\n</template>\n<template def='link-item(a, b)'>\n</template>",
+	}
+
+	hover := analyzer.Hover(doc, strings.Index(doc.Text, "link-item")+2, testFields)
+	if hover == nil || !strings.Contains(hover.Contents.Value, "link-item") {
+		t.Fatalf("the hover of a named template is not as expected: %v", hover)
+	}
+
+	hover = analyzer.Hover(doc, strings.Index(doc.Text, "Title")+1, testFields)
+	if hover == nil || !strings.Contains(hover.Contents.Value, "Title string") {
+		t.Fatalf("the hover of a def field is not as expected: %v", hover)
+	}
+
+	loc := analyzer.Definition(doc, strings.Index(doc.Text, "link-item")+2)
+	if loc == nil || loc.Range.Start != (Position{Line: 3, Character: 0}) {
+		t.Fatalf("the definition of a named template is not as expected: %v", loc)
+	}
+
+	if analyzer.Definition(doc, strings.Index(doc.Text, "template")+2) != nil {
+		t.Fatal("a component tag shouldn't have a definition")
+	}
+}
+
+func TestPosition(t *testing.T) {
+	doc := &Document{
+		Text: "ab\n中文x\n",
+	}
+
+	offset := strings.Index(doc.Text, "x")
+	pos := doc.PositionAt(offset)
+	if pos != (Position{Line: 1, Character: 2}) {
+		t.Fatalf("unexpected position: %v", pos)
+	}
+	if doc.OffsetAt(pos) != offset {
+		t.Fatalf("unexpected offset: %d", doc.OffsetAt(pos))
+	}
+}
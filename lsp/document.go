@@ -0,0 +1,98 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Document struct {
+	URI  string
+	Text string
+}
+
+// convert a byte offset into a position, counting characters in utf-16 code units as the protocol requires
+func (d *Document) PositionAt(offset int) Position {
+	if offset > len(d.Text) {
+		offset = len(d.Text)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	pos := Position{}
+	for _, ch := range d.Text[:offset] {
+		if ch == '\n' {
+			pos.Line += 1
+			pos.Character = 0
+			continue
+		}
+		pos.Character += len(utf16.Encode([]rune{ch}))
+	}
+
+	return pos
+}
+
+// convert a position into a byte offset
+func (d *Document) OffsetAt(pos Position) int {
+	line := 0
+	offset := 0
+	for line < pos.Line {
+		nl := strings.IndexByte(d.Text[offset:], '\n')
+		if nl == -1 {
+			return len(d.Text)
+		}
+		offset += nl + 1
+		line += 1
+	}
+
+	units := 0
+	for units < pos.Character && offset < len(d.Text) {
+		ch, size := utf8.DecodeRuneInString(d.Text[offset:])
+		if ch == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{ch}))
+		offset += size
+	}
+
+	return offset
+}
+
+func (d *Document) RangeOf(begin int, end int) Range {
+	return Range{
+		Start: d.PositionAt(begin),
+		End:   d.PositionAt(end),
+	}
+}
+
+// the word (letters, digits, '_' and '-') around the offset, along with its boundaries
+func (d *Document) WordAt(offset int) (string, int, int) {
+	isWordChar := func(ch byte) bool {
+		return ch == '_' || ch == '-' ||
+			(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+	}
+
+	begin := offset
+	for begin > 0 && isWordChar(d.Text[begin-1]) {
+		begin -= 1
+	}
+	end := offset
+	for end < len(d.Text) && isWordChar(d.Text[end]) {
+		end += 1
+	}
+
+	return d.Text[begin:end], begin, end
+}
+
+// the directory holding the document, if it is a local file
+func (d *Document) Dir() string {
+	u, err := url.Parse(d.URI)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+
+	return filepath.Dir(u.Path)
+}
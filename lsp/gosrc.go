@@ -0,0 +1,97 @@
+package lsp
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type DefField struct {
+	Name string
+	Type string
+	Doc  string
+}
+
+// find the exported fields of the page def structs declared in the go files of a directory.
+// a page def struct is recognized by its Tpl field.
+func ScanDefFields(dir string) []DefField {
+	fields := []DefField{}
+	if dir == "" {
+		return fields
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return fields
+	}
+
+	fset := token.NewFileSet()
+	seen := map[string]bool{}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, rerr := os.ReadFile(path)
+		if rerr != nil {
+			continue
+		}
+		file, perr := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if perr != nil {
+			continue
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			structType, ok := node.(*ast.StructType)
+			if !ok || !hasTplField(structType) {
+				return true
+			}
+			for _, field := range structType.Fields.List {
+				for _, name := range field.Names {
+					if !name.IsExported() || name.Name == "Tpl" || seen[name.Name] {
+						continue
+					}
+					seen[name.Name] = true
+					fields = append(fields, DefField{
+						Name: name.Name,
+						Type: exprString(fset, field.Type),
+						Doc:  strings.TrimSpace(field.Doc.Text() + field.Comment.Text()),
+					})
+				}
+			}
+			return false
+		})
+	}
+
+	sort.Slice(fields, func(i int, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	return fields
+}
+
+func hasTplField(structType *ast.StructType) bool {
+	for _, field := range structType.Fields.List {
+		for _, name := range field.Names {
+			if name.Name == "Tpl" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	buf := bytes.Buffer{}
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
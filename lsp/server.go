@@ -0,0 +1,230 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/TinyWisp/rview/comp"
+)
+
+type Server struct {
+	analyzer *Analyzer
+	docs     map[string]*Document
+	reader   *bufio.Reader
+	writer   io.Writer
+
+	// find the fields of the page def structs for a document directory
+	ScanDefFields func(dir string) []DefField
+}
+
+func NewServer(tagCompCreatorMap map[string]func() comp.Component) *Server {
+	return &Server{
+		analyzer:      NewAnalyzer(tagCompCreatorMap),
+		docs:          map[string]*Document{},
+		ScanDefFields: ScanDefFields,
+	}
+}
+
+// serve the requests read from r, writing the responses to w, until the client asks the server to exit
+func (s *Server) Run(r io.Reader, w io.Writer) error {
+	s.reader = bufio.NewReader(r)
+	s.writer = w
+
+	for {
+		body, err := s.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		req := request{}
+		if jerr := json.Unmarshal(body, &req); jerr != nil {
+			s.reply(nil, nil, &responseError{Code: codeParseError, Message: jerr.Error()})
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, rerr := s.handle(&req)
+		if req.ID != nil {
+			s.reply(req.ID, result, rerr)
+		}
+	}
+}
+
+func (s *Server) handle(req *request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": 1,
+				"hoverProvider":    true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"<", ":", "@", " ", "{", "\""},
+				},
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]interface{}{
+				"name": "rview-lsp",
+			},
+		}, nil
+
+	case "shutdown":
+		return nil, nil
+
+	case "textDocument/didOpen":
+		params := DidOpenTextDocumentParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		doc := &Document{
+			URI:  params.TextDocument.URI,
+			Text: params.TextDocument.Text,
+		}
+		s.docs[doc.URI] = doc
+		s.publishDiagnostics(doc)
+		return nil, nil
+
+	case "textDocument/didChange":
+		params := DidChangeTextDocumentParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		doc.Text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		s.publishDiagnostics(doc)
+		return nil, nil
+
+	case "textDocument/didClose":
+		params := DidCloseTextDocumentParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+		return nil, nil
+
+	case "textDocument/completion":
+		doc, offset, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		return s.analyzer.Complete(doc, offset, s.ScanDefFields(doc.Dir())), nil
+
+	case "textDocument/hover":
+		doc, offset, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if hover := s.analyzer.Hover(doc, offset, s.ScanDefFields(doc.Dir())); hover != nil {
+			return hover, nil
+		}
+		return nil, nil
+
+	case "textDocument/definition":
+		doc, offset, err := s.position(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		if loc := s.analyzer.Definition(doc, offset); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+}
+
+func (s *Server) position(rawParams json.RawMessage) (*Document, int, *responseError) {
+	params := TextDocumentPositionParams{}
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return nil, 0, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, 0, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown document: %s", params.TextDocument.URI)}
+	}
+
+	return doc, doc.OffsetAt(params.Position), nil
+}
+
+func (s *Server) publishDiagnostics(doc *Document) {
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         doc.URI,
+		Diagnostics: s.analyzer.Diagnose(doc),
+	})
+}
+
+func (s *Server) readMessage() ([]byte, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || strings.Contains(err.Error(), "EOF") {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %s", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (s *Server) writeMessage(msg interface{}) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) {
+	if rerr != nil {
+		s.writeMessage(errorResponse{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   rerr,
+		})
+		return
+	}
+
+	s.writeMessage(response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.writeMessage(notification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
@@ -0,0 +1,98 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/TinyWisp/rview"
+)
+
+func encodeMessages(msgs ...string) string {
+	str := ""
+	for _, msg := range msgs {
+		str += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	return str
+}
+
+func decodeMessages(t *testing.T, str string) []map[string]interface{} {
+	msgs := []map[string]interface{}{}
+	reader := bufio.NewReader(strings.NewReader(str))
+	for {
+		header, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err != nil {
+			break
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			t.Fatal(err)
+		}
+		msg := map[string]interface{}{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+func TestServerRun(t *testing.T) {
+	input := encodeMessages(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///tmp/a.rview","languageId":"rview","version":1,"text":"<template>\n  <box>\n</template>"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///tmp/a.rview"},"contentChanges":[{"text":"<template>\n  <box></box>\n</template>"}]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///tmp/a.rview"},"position":{"line":1,"character":6}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///tmp/a.rview"},"position":{"line":1,"character":0}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"unknown/method","params":{}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	output := bytes.Buffer{}
+	server := NewServer(rview.DefaultTagCompCreatorMap)
+	if err := server.Run(strings.NewReader(input), &output); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := decodeMessages(t, output.String())
+	if len(msgs) != 7 {
+		t.Fatalf("7 messages are expected, got %d", len(msgs))
+	}
+
+	if _, ok := msgs[0]["result"].(map[string]interface{})["capabilities"]; !ok {
+		t.Fatal("the initialize response should contain the capabilities")
+	}
+
+	diags := msgs[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diags) != 2 {
+		t.Fatalf("2 diagnostics are expected after opening, got %v", diags)
+	}
+	diags = msgs[2]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diags) != 0 {
+		t.Fatalf("no diagnostic is expected after fixing, got %v", diags)
+	}
+
+	items := msgs[3]["result"].([]interface{})
+	if len(items) == 0 || items[0].(map[string]interface{})["label"] != "box" {
+		t.Fatalf("tag names are expected to be completed, got %v", items)
+	}
+
+	if msgs[4]["result"] != nil {
+		t.Fatalf("no hover is expected on whitespace, got %v", msgs[4]["result"])
+	}
+
+	if msgs[5]["error"] == nil {
+		t.Fatal("an unknown method should be answered with an error")
+	}
+}
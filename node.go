@@ -20,5 +20,16 @@ type ComponentNode struct {
 	ElseIf      bool
 	HasElse     bool
 	Else        bool
-	HasFor      bool
+	HasShow     bool
+	Show        bool
+	HasMemo     bool
+	Memo        interface{}
+	// the custom directives by their keys, like tooltip:top
+	Directives map[string]*DirectiveBinding
+	HasFor     bool
+
+	// the scope of the component, kept by the nodes of the later renders, and stopped when the component is gone.
+	// the watchers of a single render, like that of v-show, are in renderScope, which is stopped when the node is replaced.
+	Scope       *EffectScope
+	renderScope *EffectScope
 }
@@ -4,23 +4,61 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/TinyWisp/rview/comp"
 	"github.com/TinyWisp/rview/ddl"
 	"github.com/TinyWisp/rview/tperr"
 )
 
+// the components available to every page
+var DefaultTagCompCreatorMap = map[string]func() comp.Component{
+	"box":        comp.CreateBox,
+	"button":     comp.CreateButton,
+	"textarea":   comp.CreateTextArea,
+	"inputfield": comp.CreateInputField,
+	"checkbox":   comp.CreateCheckbox,
+	"dropdown":   comp.CreateDropdown,
+	"form":       comp.CreateForm,
+	"flex":       comp.CreateFlex,
+	"grid":       comp.CreateGrid,
+	"image":      comp.CreateImage,
+	"table":      comp.CreateTable,
+	"list":       comp.CreateList,
+	"treeview":   comp.CreateTreeView,
+	"modal":      comp.CreateModal,
+	"template":   comp.CreateTemplate,
+	"pre":        comp.CreatePre,
+}
+
 type Page struct {
 	Tpl               string
 	TagCompCreatorMap map[string]func() comp.Component
 	tplRoot           *ddl.TplNode
 	root              *ComponentNode
 	def               interface{}
+	filters           map[string]interface{}
+	directives        map[string]*Directive
 	cache             map[string]comp.Component
+	compiled          map[*ddl.Exp]CompiledExp
+	compiledStmts     map[*ddl.Exp]CompiledStmt
+	mapOrder          func(a interface{}, b interface{}) bool
+	errorHandler      func(err error)
+	renderWatcher     *Watcher
+	renderErr         error
+	nodes             map[string]*ComponentNode
+	prevNodes         map[string]*ComponentNode
+	scope             *EffectScope
+	chanItems         map[string]chanItems
 }
 
 // get a variable for a node
 func (p *Page) getVarForNode(node *ComponentNode, varName string) (interface{}, error) {
+	// a filter of the page. the global filters are looked up by the caller.
+	if strings.HasPrefix(varName, FilterVarPrefix) {
+		return p.filters[strings.TrimPrefix(varName, FilterVarPrefix)], nil
+	}
+
 	structVar, err := GetStructField(p.def, varName)
 	if err == nil {
 		return structVar, nil
@@ -37,7 +75,283 @@ func (p *Page) getVarForNode(node *ComponentNode, varName string) (interface{},
 		curNode = curNode.Parent
 	}
 
-	return nil, tperr.NewTypedError("page.undefinedVariable", varName)
+	// a method of the page definition can be called like a function
+	if method := MethodByName(p.def, varName); method.IsValid() {
+		return method.Interface(), nil
+	}
+
+	// a built-in or registered helper function, like len or upper
+	if helper := getHelper(varName); helper != nil {
+		return helper, nil
+	}
+
+	return nil, tperr.NewTypedError("page.undefinedVariable", varName).Wrap(err)
+}
+
+// set a variable for a node, which is either a field of the page definition or a variable of a v-for.
+// the fields can only be changed if the page is created with a pointer to its definition.
+func (p *Page) setVarForNode(node *ComponentNode, varName string, val *ddl.Exp) error {
+	if _, err := GetStructField(p.def, varName); err == nil {
+		return AssignStructField(p.def, varName, val)
+	}
+
+	// a variable of a v-for only changes for the node, like a local variable
+	curNode := node
+	for curNode != nil {
+		if nodeVar, ok := curNode.Vars[varName]; ok {
+			varType := interfaceType
+			if nodeVar != nil {
+				varType = reflect.TypeOf(nodeVar)
+			}
+			goVal, ok := expToTypedValue(val, varType)
+			if !ok {
+				return tperr.NewTypedError("calc.assignmentTypeMismatch", val.ActualTypeName(), varType.String())
+			}
+			curNode.Vars[varName] = goVal.Interface()
+			return nil
+		}
+		if !curNode.InheritVars {
+			break
+		}
+		curNode = curNode.Parent
+	}
+
+	return tperr.NewTypedError("page.undefinedVariable", varName)
+}
+
+// set the function to receive the errors of the event handlers, which are dropped by default,
+// since they happen in the callbacks of the components rather than in a call of the page
+func (p *Page) SetErrorHandler(handler func(err error)) {
+	p.errorHandler = handler
+}
+
+// run the handler of an event of a node with the arguments of the event.
+// a handler which is only the name of a function, like @click="Save", is called with the arguments,
+// while the others, like @click="Count++", are executed as statements.
+func (p *Page) handleEvent(node *ComponentNode, attr *ddl.TplAttr, args []interface{}) error {
+	getVariable := func(name string) (interface{}, error) {
+		return p.getVarForNode(node, name)
+	}
+	setVariable := func(name string, val *ddl.Exp) error {
+		return p.setVarForNode(node, name, val)
+	}
+
+	if attr.Exp.Type == ddl.ExpVar {
+		handler, gerr := getVariable(attr.Exp.Variable)
+		if gerr != nil {
+			return ddl.WrapDdlError(p.Tpl, attr.ValPos, gerr)
+		}
+		if handler != nil && reflect.TypeOf(handler).Kind() == reflect.Func {
+			argExps := make([]*ddl.Exp, 0, len(args))
+			for _, arg := range args {
+				argExp, cerr := ConvertVariableToExp(arg)
+				if cerr != nil {
+					return ddl.WrapDdlError(p.Tpl, attr.ValPos, cerr)
+				}
+				argExps = append(argExps, argExp)
+			}
+			if _, err := invokeFunc(attr.Exp.Variable, reflect.ValueOf(handler), argExps); err != nil {
+				return ddl.WrapDdlError(p.Tpl, attr.ValPos, err)
+			}
+			return nil
+		}
+	}
+
+	if p.compiledStmts == nil {
+		p.compiledStmts = map[*ddl.Exp]CompiledStmt{}
+	}
+	stmt, ok := p.compiledStmts[attr.Exp]
+	if !ok {
+		stmt = CompileStmt(attr.Exp)
+		p.compiledStmts[attr.Exp] = stmt
+	}
+
+	if _, err := stmt(getVariable, setVariable); err != nil {
+		if derr, ok := err.(*ddl.DdlError); ok {
+			derr.AddOffset(attr.ValPos)
+			derr.SetDdl(p.Tpl)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// compile an expression of the template only once, however many times it is evaluated
+func (p *Page) compileTplExp(exp *ddl.Exp) CompiledExp {
+	if p.compiled == nil {
+		p.compiled = map[*ddl.Exp]CompiledExp{}
+	}
+
+	compiled, ok := p.compiled[exp]
+	if !ok {
+		compiled = CompileExp(exp)
+		p.compiled[exp] = compiled
+	}
+
+	return compiled
+}
+
+// calculate an expression of the template, making the position of any error relative to the whole template
+func (p *Page) calcTplExp(exp *ddl.Exp, offset int, varGetter VarGetter) (*ddl.Exp, error) {
+	res, err := p.compileTplExp(exp)(varGetter)
+	if err != nil {
+		if derr, ok := err.(*ddl.DdlError); ok {
+			derr.AddOffset(offset)
+			derr.SetDdl(p.Tpl)
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// an item of a v-for, whose key is the index, except for a map or an iter.Seq2, whose key is the key
+// get is set for an item of a ReactiveSlice or a ReactiveMap, which reads the item and watches it.
+type forItem struct {
+	key interface{}
+	val interface{}
+	get func() interface{}
+}
+
+// the items read from a channel by a v-for node so far, which are listed again by the later renders, as the channel is drained
+type chanItems struct {
+	ch     uintptr
+	items  []forItem
+	closed bool
+}
+
+// list the items of a v-for over a slice, an array, a map, a number, a channel, an iter.Seq or an iter.Seq2.
+// a number n gives the items from 1 to n, and a channel is read without blocking: each render lists
+// the items received by the v-for node of the key so far, plus the ones sent since the last render.
+func (p *Page) listForItems(forKey string, iterateExp *ddl.Exp) ([]forItem, bool) {
+	items := []forItem{}
+
+	if iterateExp.Type == ddl.ExpInt {
+		for i := int64(0); i < iterateExp.Int; i++ {
+			items = append(items, forItem{key: int(i), val: int(i + 1)})
+		}
+		return items, true
+	}
+
+	if iterateExp.Type != ddl.ExpInterface {
+		return nil, false
+	}
+
+	// a ReactiveSlice or a ReactiveMap, whose items are read without being watched here, as each of them is watched by its own node
+	if iterable, ok := iterateExp.Interface.(reactiveIterable); ok {
+		keys := iterable.partKeys()
+		if iterable.keyed() {
+			p.sortMapKeys(keys)
+		}
+		for _, key := range keys {
+			key := key
+			items = append(items, forItem{
+				key: key.Interface(),
+				val: iterable.peekPart(key),
+				get: func() interface{} {
+					return iterable.getPart(key)
+				},
+			})
+		}
+		return items, true
+	}
+
+	iterateVal := reflect.ValueOf(iterateExp.Interface)
+	switch iterateVal.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < iterateVal.Len(); i++ {
+			items = append(items, forItem{key: i, val: iterateVal.Index(i).Interface()})
+		}
+
+	case reflect.Map:
+		mapKeys := iterateVal.MapKeys()
+		p.sortMapKeys(mapKeys)
+		for _, mkey := range mapKeys {
+			items = append(items, forItem{key: mkey.Interface(), val: iterateVal.MapIndex(mkey).Interface()})
+		}
+
+	case reflect.Chan:
+		if iterateVal.Type().ChanDir()&reflect.RecvDir == 0 {
+			return nil, false
+		}
+		read, ok := p.chanItems[forKey]
+		if !ok || read.ch != iterateVal.Pointer() {
+			read = chanItems{ch: iterateVal.Pointer()}
+		}
+		for !read.closed {
+			val, ok := iterateVal.TryRecv()
+			if !ok {
+				// TryRecv gives a zero value for both an empty channel and a closed one
+				read.closed = val.IsValid()
+				break
+			}
+			read.items = append(read.items, forItem{key: len(read.items), val: val.Interface()})
+		}
+		if p.chanItems == nil {
+			p.chanItems = map[string]chanItems{}
+		}
+		p.chanItems[forKey] = read
+		items = append(items, read.items...)
+
+	// func(yield func(V) bool) and func(yield func(K, V) bool), which are iter.Seq and iter.Seq2
+	case reflect.Func:
+		seqType := iterateVal.Type()
+		if seqType.NumIn() != 1 || seqType.NumOut() != 0 {
+			return nil, false
+		}
+		yieldType := seqType.In(0)
+		if yieldType.Kind() != reflect.Func || yieldType.NumOut() != 1 || yieldType.Out(0).Kind() != reflect.Bool ||
+			(yieldType.NumIn() != 1 && yieldType.NumIn() != 2) {
+			return nil, false
+		}
+
+		yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			if len(args) == 1 {
+				items = append(items, forItem{key: len(items), val: args[0].Interface()})
+			} else {
+				items = append(items, forItem{key: args[0].Interface(), val: args[1].Interface()})
+			}
+			return []reflect.Value{reflect.ValueOf(true).Convert(yieldType.Out(0))}
+		})
+		iterateVal.Call([]reflect.Value{yield})
+
+	default:
+		return nil, false
+	}
+
+	return items, true
+}
+
+// golang's maps are unordered.
+// to avoid inconsistencies in the order of generated nodes each time, the keys are sorted,
+// by the MapOrder function of the page definition if there is one.
+func (p *Page) sortMapKeys(mapKeys []reflect.Value) {
+	if p.mapOrder != nil {
+		sort.SliceStable(mapKeys, func(i int, j int) bool {
+			return p.mapOrder(mapKeys[i].Interface(), mapKeys[j].Interface())
+		})
+		return
+	}
+
+	sort.SliceStable(mapKeys, func(i int, j int) bool {
+		switch mapKeys[i].Kind() {
+		case reflect.String:
+			return mapKeys[i].String() < mapKeys[j].String()
+
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return mapKeys[i].Int() < mapKeys[j].Int()
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return mapKeys[i].Uint() < mapKeys[j].Uint()
+
+		case reflect.Float32, reflect.Float64:
+			return mapKeys[i].Float() < mapKeys[j].Float()
+
+		default:
+			return false
+		}
+	})
 }
 
 func (p *Page) createCompNode(tplNode *ddl.TplNode, parent *ComponentNode) ([]*ComponentNode, error) {
@@ -55,7 +369,7 @@ func (p *Page) createCompNode(tplNode *ddl.TplNode, parent *ComponentNode) ([]*C
 	}
 	key := fmt.Sprintf("%s-%d", keyPrefix, tplNode.Idx)
 	if keyAttr, ok := tplNode.Attrs["key"]; ok {
-		ckey, err := CalcExp(keyAttr.Exp, getParentVariable)
+		ckey, err := p.calcTplExp(keyAttr.Exp, keyAttr.ValPos, getParentVariable)
 		if err != nil {
 			return empty, err
 		}
@@ -75,136 +389,61 @@ func (p *Page) createCompNode(tplNode *ddl.TplNode, parent *ComponentNode) ([]*C
 
 	// v-for
 	if tplNode.For != nil {
-		iterateExp, err := CalcExp(tplNode.For.Range, getParentVariable)
+		iterateExp, err := p.calcTplExp(tplNode.For.Range, tplNode.For.RangePos, getParentVariable)
 		if err != nil {
 			return empty, err
 		}
-		if iterateExp.Type != ddl.ExpInterface {
+		items, ok := p.listForItems(fmt.Sprintf("%s-%d", keyPrefix, tplNode.Idx), iterateExp)
+		if !ok {
 			return empty, ddl.NewDdlError(p.Tpl, tplNode.For.RangePos, "page.cannotIterateOverTheVar")
 		}
 
-		iterateVal := reflect.ValueOf(iterateExp.Interface)
-		kind := iterateVal.Kind()
-		itemVarName := tplNode.For.Val
-		idxVarName := tplNode.For.Idx
-
-		// array, slice
-		if kind == reflect.Array || kind == reflect.Slice {
-			forNodes := []*ComponentNode{}
-			for i := 0; i < iterateVal.Len(); i++ {
-				itemVal := iterateVal.Index(i)
-				copyTplNode := *tplNode
-				copyTplNode.For = nil
-				copyCompNode := *compNode
-				copyCompNode.Key = fmt.Sprintf("%s-%d", keyPrefix, i)
-				copyCompNode.Vars = map[string]interface{}{}
-				copyCompNode.Vars[itemVarName] = itemVal.Interface()
-				copyCompNode.Vars[idxVarName] = i
-				if keyAttr, ok := copyTplNode.Attrs["key"]; ok {
-					getCurrentVariable := func(varName string) (interface{}, error) {
-						return p.getVarForNode(&copyCompNode, varName)
-					}
-					ckey, err := CalcExp(keyAttr.Exp, getCurrentVariable)
-					if err != nil {
-						return empty, err
-					}
-					copyCompNode.Key = fmt.Sprintf("%s-%s", keyPrefix, ckey.ToString())
-				}
-				comp, cerr := p.createComponentAndSetProps(&copyCompNode, &copyTplNode, copyCompNode.Key)
-				if cerr != nil {
-					return empty, cerr
-				}
-				copyCompNode.Comp = comp
-
-				if len(copyTplNode.Children) > 0 {
-					for _, childTplNode := range copyTplNode.Children {
-						childComponentNode, ccerr := p.createCompNode(childTplNode, &copyCompNode)
-						if ccerr != nil {
-							return empty, ccerr
-						}
-						copyCompNode.Children = append(copyCompNode.Children, childComponentNode...)
-					}
-				}
-
-				forNodes = append(forNodes, &copyCompNode)
+		forNodes := []*ComponentNode{}
+		for i, item := range items {
+			copyTplNode := *tplNode
+			copyTplNode.For = nil
+			copyCompNode := *compNode
+			// the index of the template node keeps the items apart from the siblings of the v-for node
+			copyCompNode.Key = fmt.Sprintf("%s-%d-%d", keyPrefix, tplNode.Idx, i)
+			copyCompNode.Vars = map[string]interface{}{}
+			copyCompNode.Vars[tplNode.For.Val] = item.val
+			if tplNode.For.Idx != "" {
+				copyCompNode.Vars[tplNode.For.Idx] = item.key
 			}
-
-			return forNodes, nil
-		}
-
-		// map
-		if kind == reflect.Map {
-			forNodes := []*ComponentNode{}
-			mapKeys := iterateVal.MapKeys()
-
-			// golang's maps are unordered.
-			// to avoid inconsistencies in the order of generated nodes each time, sorting is necessary.
-			sort.SliceStable(mapKeys, func(i int, j int) bool {
-				switch mapKeys[i].Kind() {
-				case reflect.String:
-					return mapKeys[i].String() < mapKeys[j].String()
-
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					return mapKeys[i].Int() < mapKeys[j].Int()
-
-				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-					return mapKeys[i].Uint() < mapKeys[j].Uint()
-
-				case reflect.Float32, reflect.Float64:
-					return mapKeys[i].Float() < mapKeys[j].Float()
-
-				default:
-					return false
-				}
-			})
-
-			for i := 0; i < len(mapKeys); i++ {
-				mkey := mapKeys[i]
-				mval := iterateVal.MapIndex(mkey)
-				copyTplNode := *tplNode
-				copyTplNode.For = nil
-				copyCompNode := *compNode
-				copyCompNode.Key = fmt.Sprintf("%s-%d", keyPrefix, i)
-				copyCompNode.Vars = map[string]interface{}{}
-				copyCompNode.Vars[itemVarName] = mval.Interface()
-				copyCompNode.Vars[idxVarName] = mkey.Interface()
-				if keyAttr, ok := copyTplNode.Attrs["key"]; ok {
-					getCurrentVariable := func(varName string) (interface{}, error) {
-						return p.getVarForNode(&copyCompNode, varName)
-					}
-					ckey, err := CalcExp(keyAttr.Exp, getCurrentVariable)
-					if err != nil {
-						return empty, err
-					}
-					copyCompNode.Key = fmt.Sprintf("%s-%s", keyPrefix, ckey.ToString())
-				}
-				comp, cerr := p.createComponentAndSetProps(&copyCompNode, &copyTplNode, copyCompNode.Key)
-				if cerr != nil {
-					return empty, cerr
+			if tplNode.For.Total != "" {
+				copyCompNode.Vars[tplNode.For.Total] = len(items)
+			}
+			if keyAttr, ok := copyTplNode.Attrs["key"]; ok {
+				getCurrentVariable := func(varName string) (interface{}, error) {
+					return p.getVarForNode(&copyCompNode, varName)
 				}
-				copyCompNode.Comp = comp
-
-				if len(copyTplNode.Children) > 0 {
-					for _, childTplNode := range copyTplNode.Children {
-						childComponentNode, ccerr := p.createCompNode(childTplNode, &copyCompNode)
-						if ccerr != nil {
-							return empty, ccerr
-						}
-						copyCompNode.Children = append(copyCompNode.Children, childComponentNode...)
-					}
+				ckey, err := p.calcTplExp(keyAttr.Exp, keyAttr.ValPos, getCurrentVariable)
+				if err != nil {
+					return empty, err
 				}
+				copyCompNode.Key = fmt.Sprintf("%s-%s", keyPrefix, ckey.ToString())
+			}
 
-				forNodes = append(forNodes, &copyCompNode)
+			itemNode, err := (*ComponentNode)(nil), error(nil)
+			if item.get != nil {
+				itemNode, err = p.watchForItem(&copyCompNode, &copyTplNode, tplNode.For.Val, item.get)
+			} else {
+				itemNode, err = p.renderForItem(&copyCompNode, &copyTplNode)
+			}
+			if err != nil {
+				return empty, err
 			}
 
-			return forNodes, nil
+			forNodes = append(forNodes, itemNode)
 		}
+
+		return forNodes, nil
 	}
 
 	// v-if
 	if tplNode.If != nil {
 		compNode.HasIf = true
-		res, err := CalcExp(tplNode.If.Exp, getParentVariable)
+		res, err := p.calcTplExp(tplNode.If.Exp, tplNode.If.ValPos, getParentVariable)
 		if err != nil {
 			return empty, err
 		}
@@ -229,12 +468,12 @@ func (p *Page) createCompNode(tplNode *ddl.TplNode, parent *ComponentNode) ([]*C
 			compNode.Ignore = true
 			return empty, nil
 		}
-		res, err := CalcExp(compNode.TplNode.ElseIf.Exp, getParentVariable)
+		res, err := p.calcTplExp(tplNode.ElseIf.Exp, tplNode.ElseIf.ValPos, getParentVariable)
 		if err != nil {
 			return empty, err
 		}
 		if res.Type != ddl.ExpBool {
-			return empty, ddl.NewDdlError(p.Tpl, tplNode.If.Pos, "page.velseifDirectriveMustBeBool", res.ActualTypeName())
+			return empty, ddl.NewDdlError(p.Tpl, tplNode.ElseIf.Pos, "page.velseifDirectriveMustBeBool", res.ActualTypeName())
 		}
 		compNode.ElseIf = res.Bool
 		compNode.Ignore = !res.Bool
@@ -262,24 +501,271 @@ func (p *Page) createCompNode(tplNode *ddl.TplNode, parent *ComponentNode) ([]*C
 		return []*ComponentNode{compNode}, nil
 	}
 
-	// create component instance
-	comp, cerr := p.createComponentAndSetProps(compNode, tplNode, compNode.Key)
-	if cerr != nil {
-		return empty, cerr
+	// v-once and v-memo
+	prevNode, err := p.reusableNode(compNode, tplNode)
+	if err != nil {
+		return empty, err
+	}
+	if prevNode != nil {
+		return []*ComponentNode{prevNode}, nil
+	}
+
+	if err := p.renderNode(compNode, tplNode); err != nil {
+		return empty, err
+	}
+
+	return []*ComponentNode{compNode}, nil
+}
+
+// render an item of a v-for, or get the node of the last render if it has v-once or an unchanged v-memo
+func (p *Page) renderForItem(node *ComponentNode, tplNode *ddl.TplNode) (*ComponentNode, error) {
+	prevNode, err := p.reusableNode(node, tplNode)
+	if err != nil {
+		return nil, err
+	}
+	if prevNode != nil {
+		return prevNode, nil
+	}
+
+	if err := p.renderNode(node, tplNode); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// create the component of a node and its children.
+// the watchers of the node, like that of v-show, are collected by its render scope, rather than by running it in the scope,
+// which would look up the goroutine for each node.
+func (p *Page) renderNode(node *ComponentNode, tplNode *ddl.TplNode) error {
+	if prevNode, ok := p.prevNodes[node.Key]; ok && prevNode.Scope != nil {
+		node.Scope = prevNode.Scope
+	} else {
+		node.Scope = newEffectScope(p.scope)
+	}
+
+	comp, err := p.createComponentAndSetProps(node, tplNode, node.Key)
+	if err != nil {
+		return err
+	}
+	node.Comp = comp
+	p.nodes[node.Key] = node
+
+	return p.createChildren(node, tplNode)
+}
+
+// render an item of a v-for over a ReactiveSlice or a ReactiveMap by a watcher of its own, which reads the item,
+// so that a changed item is rendered again by itself, rather than along with the whole page.
+func (p *Page) watchForItem(node *ComponentNode, tplNode *ddl.TplNode, valName string, get func() interface{}) (*ComponentNode, error) {
+	rendered := (*ComponentNode)(nil)
+	err := error(nil)
+
+	render := func() {
+		if rendered == nil {
+			node.Vars[valName] = get()
+			rendered, err = p.renderForItem(node, tplNode)
+			return
+		}
+
+		fresh := *rendered
+		fresh.Vars = map[string]interface{}{}
+		for k, v := range rendered.Vars {
+			fresh.Vars[k] = v
+		}
+		fresh.Vars[valName] = get()
+		fresh.Children = nil
+		fresh.HasMemo, fresh.Memo = false, nil
+		fresh.Directives = nil
+		rendered, err = p.rerenderNode(rendered, &fresh)
+	}
+
+	// the watcher is collected by the render scope of the parent node, as it renders the item again by itself
+	stop := runAndWatch(render, func(watcher *Watcher) {
+		watcher.RunAndWatch()
+		if err != nil && p.errorHandler != nil {
+			p.errorHandler(err)
+		}
+	})
+	p.addRenderEffect(node.Parent, stop)
+
+	return rendered, err
+}
+
+// render a node again, and put the result in place of the old node among the children of its parent
+func (p *Page) rerenderNode(old *ComponentNode, fresh *ComponentNode) (*ComponentNode, error) {
+	prevNodes := p.prevNodes
+	p.prevNodes = map[string]*ComponentNode{}
+	p.moveToPrevNodes(old)
+	defer func() {
+		p.prevNodes = prevNodes
+	}()
+
+	node, err := p.renderForItem(fresh, fresh.TplNode)
+	if err != nil {
+		p.keepNodes(old)
+		return old, err
+	}
+	if node != old && old.Parent != nil {
+		for i, child := range old.Parent.Children {
+			if child == old {
+				old.Parent.Children[i] = node
+			}
+		}
+	}
+
+	return node, p.unmountNodes()
+}
+
+func (p *Page) moveToPrevNodes(node *ComponentNode) {
+	p.prevNodes[node.Key] = node
+	if p.nodes[node.Key] == node {
+		delete(p.nodes, node.Key)
+	}
+	for _, child := range node.Children {
+		if child.Comp != nil {
+			p.moveToPrevNodes(child)
+		}
+	}
+}
+
+// get the node made by the last render, which is kept along with its children instead of being rendered again,
+// if the node has v-once, or if the values listed by its v-memo are the same as the last time.
+// the v-if and the v-for of the node are still evaluated, so a node with v-once can be added or removed like any other.
+func (p *Page) reusableNode(node *ComponentNode, tplNode *ddl.TplNode) (*ComponentNode, error) {
+	if tplNode.Once == nil && tplNode.Memo == nil {
+		return nil, nil
+	}
+
+	if tplNode.Memo != nil {
+		getVariable := func(varName string) (interface{}, error) {
+			return p.getVarForNode(node, varName)
+		}
+		res, err := p.calcTplExp(tplNode.Memo.Exp, tplNode.Memo.ValPos, getVariable)
+		if err != nil {
+			return nil, err
+		}
+		if res.Type != ddl.ExpInterface || reflect.ValueOf(res.Interface).Kind() != reflect.Slice {
+			return nil, ddl.NewDdlError(p.Tpl, tplNode.Memo.Pos, "page.vmemoDirectiveMustBeArray", res.ActualTypeName())
+		}
+		node.HasMemo = true
+		node.Memo = res.Interface
 	}
 
-	compNode.Comp = comp
+	prevNode, ok := p.prevNodes[node.Key]
+	if !ok || prevNode.Comp == nil || prevNode.HasMemo != node.HasMemo || !reflect.DeepEqual(prevNode.Memo, node.Memo) {
+		return nil, nil
+	}
+
+	prevNode.Parent = node.Parent
+	p.keepNodes(prevNode)
+	return prevNode, nil
+}
+
+// keep the nodes of the last render for the next one
+func (p *Page) keepNodes(node *ComponentNode) {
+	p.nodes[node.Key] = node
+	for _, child := range node.Children {
+		if child.Comp != nil {
+			p.keepNodes(child)
+		}
+	}
+}
+
+func (p *Page) createChildren(compNode *ComponentNode, tplNode *ddl.TplNode) error {
+	getVariable := func(varName string) (interface{}, error) {
+		return p.getVarForNode(compNode, varName)
+	}
+
+	// the text and the interpolations inside a tag, like the content of <pre> or "Total: {{ Price | currency }}",
+	// make up the "text" prop of the component, which only the components with a text prop can have
+	for _, childTplNode := range tplNode.Children {
+		if childTplNode.Type != ddl.TplNodeText && childTplNode.Type != ddl.TplNodeExp {
+			continue
+		}
+		if !hasTextProp(compNode.Comp) {
+			return ddl.NewDdlError(p.Tpl, childTplNode.Pos, "page.textContentNotSupported", tplNode.TagName)
+		}
+		break
+	}
+
+	text := ""
+	textPos := -1
+	for idx, childTplNode := range tplNode.Children {
+		if childTplNode.Type != ddl.TplNodeText && childTplNode.Type != ddl.TplNodeExp {
+			continue
+		}
+
+		// the text nodes are trimmed, so the spaces around the interpolations are recovered from the template
+		if idx > 0 && textPos >= 0 && !strings.HasSuffix(text, " ") {
+			prevType := tplNode.Children[idx-1].Type
+			if childTplNode.Type == ddl.TplNodeExp && isSpaceAt(p.Tpl, childTplNode.Pos-3) {
+				text += " "
+			} else if childTplNode.Type == ddl.TplNodeText && prevType == ddl.TplNodeExp && isSpaceAt(p.Tpl, childTplNode.Pos) {
+				text += " "
+			}
+		}
+
+		if childTplNode.Type == ddl.TplNodeText {
+			text += childTplNode.Text
+		} else {
+			res, err := p.calcTplExp(childTplNode.Exp, childTplNode.Pos, getVariable)
+			if err != nil {
+				return err
+			}
+			text += interpolationToStr(res)
+		}
+		if textPos < 0 {
+			textPos = childTplNode.Pos
+		}
+	}
+	if textPos >= 0 {
+		if err := compNode.Comp.SetProp("text", text); err != nil {
+			return ddl.WrapDdlError(p.Tpl, textPos, err)
+		}
+	}
 
-	// children
 	for _, childTplNode := range tplNode.Children {
+		if childTplNode.Type != ddl.TplNodeTag {
+			continue
+		}
+
 		childCompNodes, cerr := p.createCompNode(childTplNode, compNode)
 		if cerr != nil {
-			return empty, cerr
+			return cerr
 		}
 		compNode.Children = append(compNode.Children, childCompNodes...)
 	}
 
-	return []*ComponentNode{compNode}, nil
+	return nil
+}
+
+// whether the component declares a text prop. a component that doesn't list its props is left to SetProp.
+func hasTextProp(component comp.Component) bool {
+	typed, ok := component.(interface {
+		GetPropTypes() map[string]reflect.Type
+	})
+	if !ok {
+		return true
+	}
+	_, ok = typed.GetPropTypes()["text"]
+	return ok
+}
+
+func isSpaceAt(str string, pos int) bool {
+	return pos >= 0 && pos < len(str) && strings.ContainsRune(" \t\r\n", rune(str[pos]))
+}
+
+// the text form of a value in {{ ... }}
+func interpolationToStr(exp *ddl.Exp) string {
+	switch exp.Type {
+	case ddl.ExpInt, ddl.ExpFloat:
+		return numToStr(exp)
+
+	case ddl.ExpNil:
+		return ""
+	}
+
+	return exp.ToString()
 }
 
 func (p *Page) createComponentAndSetProps(node *ComponentNode, tplNode *ddl.TplNode, key string) (comp.Component, error) {
@@ -305,7 +791,7 @@ func (p *Page) createComponentAndSetProps(node *ComponentNode, tplNode *ddl.TplN
 			continue
 		}
 
-		exp, err := CalcExp(attr.Exp, getVariable)
+		exp, err := p.calcTplExp(attr.Exp, attr.ValPos, getVariable)
 		if err != nil {
 			return nil, err
 		}
@@ -328,11 +814,38 @@ func (p *Page) createComponentAndSetProps(node *ComponentNode, tplNode *ddl.TplN
 		}
 
 		if err != nil {
-			if terr, ok := err.(*tperr.TypedError); ok {
-				derr := ddl.NewDdlError(p.Tpl, attr.Pos, terr.GetEtype(), terr.GetVars()...)
-				return nil, derr
+			return nil, ddl.WrapDdlError(p.Tpl, attr.Pos, err)
+		}
+	}
+
+	// bind the event handlers
+	for event, attr := range tplNode.Events {
+		binder, ok := comp.(interface {
+			SetEventHandler(event string, handler func(args ...interface{})) error
+		})
+		if !ok {
+			return nil, ddl.NewDdlError(p.Tpl, attr.Pos, "comp.SetEventHandler.eventNotSupported", event, comp.GetName())
+		}
+
+		attr := attr
+		err := binder.SetEventHandler(event, func(args ...interface{}) {
+			if err := p.handleEvent(node, attr, args); err != nil && p.errorHandler != nil {
+				p.errorHandler(err)
 			}
+		})
+		if err != nil {
+			return nil, ddl.WrapDdlError(p.Tpl, attr.Pos, err)
+		}
+	}
+
+	// custom directives
+	if err := p.bindDirectives(node, tplNode, comp); err != nil {
+		return nil, err
+	}
 
+	// v-show
+	if tplNode.Show != nil {
+		if err := p.watchShow(node, tplNode.Show, comp); err != nil {
 			return nil, err
 		}
 	}
@@ -340,6 +853,143 @@ func (p *Page) createComponentAndSetProps(node *ComponentNode, tplNode *ddl.TplN
 	return comp, nil
 }
 
+// collect the stop func of a watcher made by a render of a node, which is stopped when the node is rendered again
+func (p *Page) addRenderEffect(node *ComponentNode, stop func()) {
+	if node.renderScope == nil {
+		node.renderScope = newEffectScope(node.Scope)
+	}
+	node.renderScope.add(stop)
+}
+
+// show or hide a component by its v-show directive, keeping it alive either way.
+// the directive is evaluated by a watcher, so the component is shown or hidden again whenever a Ref it reads is changed.
+func (p *Page) watchShow(node *ComponentNode, attr *ddl.TplAttr, component comp.Component) error {
+	getVariable := func(name string) (interface{}, error) {
+		return p.getVarForNode(node, name)
+	}
+
+	apply := func() error {
+		res, err := p.calcTplExp(attr.Exp, attr.ValPos, getVariable)
+		if err != nil {
+			return err
+		}
+		if res.Type != ddl.ExpBool {
+			return ddl.NewDdlError(p.Tpl, attr.Pos, "page.vshowDirectiveMustBeBool", res.ActualTypeName())
+		}
+
+		node.HasShow = true
+		node.Show = res.Bool
+		if err := component.SetProp("visible", res.Bool); err != nil {
+			return ddl.WrapDdlError(p.Tpl, attr.Pos, err)
+		}
+		return nil
+	}
+
+	// the watcher is collected by the render scope of the node, which is stopped when the node is rendered again
+	err := error(nil)
+	stop := runAndWatch(func() {
+		err = apply()
+	}, func(watcher *Watcher) {
+		watcher.RunAndWatch()
+		if err != nil && p.errorHandler != nil {
+			p.errorHandler(err)
+		}
+	})
+	p.addRenderEffect(node, stop)
+
+	return err
+}
+
+// render the template again, which reuses the components by their keys.
+// a page is rendered by NewPage, and again whenever a Ref read by the last render is changed.
+func (p *Page) Render() error {
+	if p.scope.isStopped() {
+		return nil
+	}
+	if p.renderWatcher == nil {
+		return p.render()
+	}
+
+	p.renderWatcher.RunAndWatch()
+	return p.renderErr
+}
+
+func (p *Page) render() error {
+	p.prevNodes, p.nodes = p.nodes, map[string]*ComponentNode{}
+	defer func() {
+		p.prevNodes = nil
+	}()
+
+	nodes, err := p.createCompNode(p.tplRoot, nil)
+	if err != nil {
+		// the nodes which are not rendered again are still shown, so they are kept for the next render
+		for key, prevNode := range p.prevNodes {
+			if _, ok := p.nodes[key]; !ok {
+				p.nodes[key] = prevNode
+			}
+		}
+		p.stopNodeScopes()
+		return err
+	}
+	validRootNodeCount := 0
+	for _, cnode := range nodes[0].Children {
+		if !cnode.Ignore {
+			validRootNodeCount += 1
+		}
+	}
+	if validRootNodeCount == 0 {
+		return tperr.NewTypedError("page.tplMustContainOneRootNode")
+	}
+	if validRootNodeCount > 1 {
+		return tperr.NewTypedError("page.tplMustContainExactlyOneRootNode")
+	}
+	p.root = nodes[0]
+
+	return p.unmountNodes()
+}
+
+// stop the render scopes of the nodes replaced by a render, and the scopes of the components which are gone.
+// it is called with p.prevNodes holding the nodes of the last render.
+func (p *Page) stopNodeScopes() {
+	for key, prevNode := range p.prevNodes {
+		node := p.nodes[key]
+		if node == prevNode {
+			continue
+		}
+
+		if prevNode.renderScope != nil {
+			prevNode.renderScope.Stop()
+		}
+		if prevNode.Scope != nil && (node == nil || node.Scope != prevNode.Scope) {
+			prevNode.Scope.Stop()
+		}
+	}
+}
+
+// clean up after the nodes of the last render which are gone, like calling Unbind for their directives
+func (p *Page) unmountNodes() error {
+	err := p.unbindDirectives()
+	p.stopNodeScopes()
+	return err
+}
+
+// stop all the watchers of the page, which is never rendered again, and call Unbind for the directives
+func (p *Page) Unmount() error {
+	p.prevNodes, p.nodes = p.nodes, map[string]*ComponentNode{}
+	defer func() {
+		p.prevNodes = nil
+	}()
+	err := p.unbindDirectives()
+	p.scope.Stop()
+
+	return err
+}
+
+// the scope of the page, which is stopped by Unmount, for the watchers which live as long as the page
+func (p *Page) Scope() *EffectScope {
+	return p.scope
+}
+
 func NewPage(def interface{}) (*Page, error) {
 	p := &Page{
 		def:   def,
@@ -370,22 +1020,9 @@ func NewPage(def interface{}) (*Page, error) {
 
 	// TagCompCreatorMap
 	icomponents, err := GetStructField(p.def, "Components")
-	p.TagCompCreatorMap = map[string]func() comp.Component{
-		"box":        comp.CreateBox,
-		"button":     comp.CreateButton,
-		"textarea":   comp.CreateTextArea,
-		"inputfield": comp.CreateInputField,
-		"checkbox":   comp.CreateCheckbox,
-		"dropdown":   comp.CreateDropdown,
-		"form":       comp.CreateForm,
-		"flex":       comp.CreateFlex,
-		"grid":       comp.CreateGrid,
-		"image":      comp.CreateImage,
-		"table":      comp.CreateTable,
-		"list":       comp.CreateList,
-		"treeview":   comp.CreateTreeView,
-		"modal":      comp.CreateModal,
-		"template":   comp.CreateTemplate,
+	p.TagCompCreatorMap = map[string]func() comp.Component{}
+	for k, v := range DefaultTagCompCreatorMap {
+		p.TagCompCreatorMap[k] = v
 	}
 	if err == nil {
 		tagCompCreatorMap, ok := icomponents.(map[string]func() comp.Component)
@@ -397,24 +1034,62 @@ func NewPage(def interface{}) (*Page, error) {
 		}
 	}
 
-	// root
-	nodes, err := p.createCompNode(p.tplRoot, nil)
-	if err != nil {
-		return nil, err
+	// filters
+	ifilters, err := GetStructField(p.def, "Filters")
+	p.filters = map[string]interface{}{}
+	if err == nil {
+		filterMap, ok := ifilters.(map[string]interface{})
+		if !ok {
+			return nil, tperr.NewTypedError("page.invalidTypeOfFiltersField")
+		}
+		for k, v := range filterMap {
+			if ferr := checkFilter(k, v); ferr != nil {
+				return nil, ferr
+			}
+			p.filters[k] = v
+		}
 	}
-	validRootNodeCount := 0
-	for _, cnode := range nodes[0].Children {
-		if !cnode.Ignore {
-			validRootNodeCount += 1
+
+	// directives
+	idirectives, err := GetStructField(p.def, "Directives")
+	p.directives = map[string]*Directive{}
+	if err == nil {
+		directiveMap, ok := idirectives.(map[string]*Directive)
+		if !ok {
+			return nil, tperr.NewTypedError("page.invalidTypeOfDirectivesField")
+		}
+		for k, v := range directiveMap {
+			if derr := checkDirective(k, v); derr != nil {
+				return nil, derr
+			}
+			p.directives[k] = v
 		}
 	}
-	if validRootNodeCount == 0 {
-		return nil, tperr.NewTypedError("page.tplMustContainOneRootNode")
+
+	// the order of the keys of a map in a v-for
+	imapOrder, err := GetStructField(p.def, "MapOrder")
+	if err == nil && imapOrder != nil {
+		mapOrder, ok := imapOrder.(func(a interface{}, b interface{}) bool)
+		if !ok {
+			return nil, tperr.NewTypedError("page.invalidTypeOfMapOrderField")
+		}
+		p.mapOrder = mapOrder
 	}
-	if validRootNodeCount > 1 {
-		return nil, tperr.NewTypedError("page.tplMustContainExactlyOneRootNode")
+
+	// root
+	p.scope = NewEffectScope()
+	p.renderWatcher = NewWatcher(func() {
+		p.renderErr = p.render()
+	}, func(ref Watchable, newVal interface{}, oldVal interface{}) {
+		if err := p.Render(); err != nil && p.errorHandler != nil {
+			p.errorHandler(err)
+		}
+	})
+	p.scope.add(p.renderWatcher.clean)
+	if p.renderErr != nil {
+		p.scope.Stop()
+		return nil, p.renderErr
 	}
-	p.root = nodes[0]
 
 	return p, nil
 }
@@ -1,16 +1,22 @@
 package rview
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/TinyWisp/rview/comp"
 	"github.com/TinyWisp/rview/ddl"
 	"github.com/TinyWisp/rview/tperr"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
 )
 
 type TestDef struct {
 	Tpl            string
 	Components     map[string]func() comp.Component
+	Filters        map[string]interface{}
 	ChickenStruct  AnimalStruct
 	DuckStruct     AnimalStruct
 	Int8Var2       int8
@@ -73,6 +79,22 @@ var testDef = TestDef{
 	FuncTimes:                  func(a int, b int) int { return a * b },
 	FuncDivision:               func(a int, b int) int { return a / b },
 	FuncPlusReturnMulti:        func(a int, b int) (int, error) { return a + b, nil },
+
+	Filters: map[string]interface{}{
+		"shout": func(str string) string { return strings.ToUpper(str) + "!" },
+	},
+}
+
+func (d TestDef) Greet(name string) string {
+	return d.StringVarHello + " " + name
+}
+
+func (d *TestDef) Shout(words ...string) string {
+	return strings.ToUpper(strings.Join(words, " "))
+}
+
+func (d TestDef) Fail() (string, error) {
+	return "", errors.New("failed")
 }
 
 // -------------------------------- test creating nodes ------------------------------------
@@ -1246,9 +1268,9 @@ func TestCreateNode(t *testing.T) {
 
 		if err != nil && testCase.err != "" {
 			notExpectedErr := true
-			if terr, ok := err.(*tperr.TypedError); ok && terr.Is(testCase.err) {
+			if terr, ok := err.(*tperr.TypedError); ok && terr.IsType(testCase.err) {
 				notExpectedErr = false
-			} else if derr, ok := err.(*ddl.DdlError); ok && derr.Is(testCase.err) {
+			} else if derr, ok := err.(*ddl.DdlError); ok && derr.IsType(testCase.err) {
 				notExpectedErr = false
 			}
 			if notExpectedErr {
@@ -1315,6 +1337,111 @@ var setPropTestCases = []TestSetPropCase{
 			return err == nil && text.(string) == "hello"
 		},
 	},
+	{
+		tpl: "<template>\n<pre>\n  a <b> &amp; {{ c }}\n</pre>\n</template>",
+		expect: func(root *ComponentNode) bool {
+			if len(root.Children) != 1 {
+				return false
+			}
+
+			node := root.Children[0]
+			text, err := node.Comp.GetProp("text")
+
+			return err == nil && text.(string) == "  a <b> & {{ c }}\n"
+		},
+	},
+	{
+		tpl: `<template>
+				<box>hello</box>
+			</template>
+			`,
+		err: "page.textContentNotSupported",
+	},
+	{
+		tpl: `<template>
+				<button>{{ StringVarHello }}</button>
+			</template>
+			`,
+		err: "page.textContentNotSupported",
+	},
+	{
+		tpl: `<template>
+				<textarea :text="Greet(StringVarWorld) + Shout(ChickenStruct.Name, DuckStruct.Name)" />
+			</template>
+			`,
+		expect: func(root *ComponentNode) bool {
+			if len(root.Children) != 1 {
+				return false
+			}
+
+			node := root.Children[0]
+			text, err := node.Comp.GetProp("text")
+
+			return err == nil && text.(string) == "hello worldCHICKEN DUCK"
+		},
+	},
+	{
+		tpl: `<template>
+				<flex>
+					<textarea v-for="(idx, item) of [StringVarHello, 'world']" :text="item + {a: '!', b: '?'}.a" />
+				</flex>
+			</template>
+			`,
+		expect: func(root *ComponentNode) bool {
+			if len(root.Children) != 1 || len(root.Children[0].Children) != 2 {
+				return false
+			}
+
+			text0, err0 := root.Children[0].Children[0].Comp.GetProp("text")
+			text1, err1 := root.Children[0].Children[1].Comp.GetProp("text")
+
+			return err0 == nil && err1 == nil && text0.(string) == "hello!" && text1.(string) == "world!"
+		},
+	},
+	{
+		tpl: `<template>
+				<textarea>Hi, {{ StringVarWorld | shout }} {{ Float64Var3 }}!</textarea>
+			</template>
+			`,
+		expect: func(root *ComponentNode) bool {
+			if len(root.Children) != 1 {
+				return false
+			}
+
+			text, err := root.Children[0].Comp.GetProp("text")
+
+			return err == nil && text.(string) == "Hi, WORLD! 3!"
+		},
+	},
+	{
+		tpl: `<template>
+				<textarea :text="NilVar?.Value ?? MapStrStr?.missing ?? 'none'" />
+			</template>
+			`,
+		expect: func(root *ComponentNode) bool {
+			if len(root.Children) != 1 {
+				return false
+			}
+
+			text, err := root.Children[0].Comp.GetProp("text")
+
+			return err == nil && text.(string) == "none"
+		},
+	},
+	{
+		tpl: `<template>
+				<textarea :text="StringVarHello | nope" />
+			</template>
+			`,
+		err: "calc.filterNotExist",
+	},
+	{
+		tpl: `<template>
+				<textarea :text="Fail()" />
+			</template>
+			`,
+		err: "calc.funcReturnedError",
+	},
 }
 
 func TestSetProp(t *testing.T) {
@@ -1332,9 +1459,9 @@ func TestSetProp(t *testing.T) {
 
 		if err != nil && testCase.err != "" {
 			notExpectedErr := true
-			if terr, ok := err.(*tperr.TypedError); ok && terr.Is(testCase.err) {
+			if terr, ok := err.(*tperr.TypedError); ok && terr.IsType(testCase.err) {
 				notExpectedErr = false
-			} else if derr, ok := err.(*ddl.DdlError); ok && derr.Is(testCase.err) {
+			} else if derr, ok := err.(*ddl.DdlError); ok && derr.IsType(testCase.err) {
 				notExpectedErr = false
 			}
 			if notExpectedErr {
@@ -1349,3 +1476,704 @@ func TestSetProp(t *testing.T) {
 		}
 	}
 }
+
+func TestExpErrorPosition(t *testing.T) {
+	tpls := []string{
+		"<template>\n  <box :title=\"1 + nope\"></box>\n</template>",
+		"<template>\n  <box v-if=\"1 + nope\"></box>\n</template>",
+		"<template>\n  <box v-for=\"(idx, item) of nope\"></box>\n</template>",
+	}
+	for _, tpl := range tpls {
+		t.Log(tpl)
+
+		def := testDef
+		def.Tpl = tpl
+		_, err := NewPage(def)
+		derr, ok := err.(*ddl.DdlError)
+		if !ok {
+			t.Fatalf("a ddl error is expected, got: %v", err)
+		}
+
+		diag := derr.Diagnostic()
+		expect := strings.Index(tpl, "nope")
+		if diag.Span == nil || diag.Span.Start.Offset != expect || diag.Span.Start.Line != 2 {
+			t.Fatalf("the error is expected at %d, got: %v", expect, diag.Span)
+		}
+	}
+}
+
+func TestErrorChain(t *testing.T) {
+	def := testDef
+	def.Tpl = `<template><box :title="nope"></box></template>`
+	_, err := NewPage(def)
+	if !errors.Is(err, tperr.ErrPageUndefinedVariable) || !errors.Is(err, tperr.ErrUtilGetStructFieldFieldNotExist) {
+		t.Fatalf("the error should wrap the page and util errors, got: %v", err)
+	}
+	var verr *tperr.VariableError
+	if !errors.As(err, &verr) || verr.Name != "nope" {
+		t.Fatalf("unexpected variable error: %v", verr)
+	}
+
+	def.Tpl = `<template><box :title="1 + true"></box></template>`
+	_, err = NewPage(def)
+	var operr *tperr.OperandTypeError
+	if !errors.As(err, &operr) || operr.Operator != "+" {
+		t.Fatalf("unexpected operand type error: %v", err)
+	}
+
+	def.Tpl = `<template><box titl="a"></box></template>`
+	_, err = NewPage(def)
+	var perr *tperr.PropError
+	if !errors.Is(err, tperr.ErrCompSetPropPropNotAllowed) || !errors.As(err, &perr) || perr.Prop != "titl" {
+		t.Fatalf("unexpected prop error: %v", err)
+	}
+	var derr *ddl.DdlError
+	if !errors.As(err, &derr) || derr.GetPos() != strings.Index(def.Tpl, "titl") {
+		t.Fatalf("the error should be located in the template: %v", err)
+	}
+
+	def.Tpl = `<template><box>label</box></template>`
+	_, err = NewPage(def)
+	if !errors.Is(err, tperr.ErrPageTextContentNotSupported) || !errors.As(err, &derr) || derr.GetPos() != strings.Index(def.Tpl, "label") {
+		t.Fatalf("the text content of a box should be an error located in the template: %v", err)
+	}
+}
+
+// the expressions of a v-for are compiled once, and evaluated for every row
+func BenchmarkNewPageVfor(b *testing.B) {
+	def := testDef
+	def.Tpl = `<template>
+				<flex>
+					<textarea v-for="(idx, el) of ArrInt" :text="idx % 2 == 0 ? StringVarHello + ChickenStruct.Name : Greet(StringVarWorld)" />
+				</flex>
+			</template>`
+	def.ArrInt = make([]int, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewPage(def); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEventHandler(t *testing.T) {
+	def := testDef
+	def.MapStrStr = map[string]string{}
+	def.Tpl = `<template>
+				<flex>
+					<button @click="Int8Var2++; StringVarHello = StringVarHello + '!'" />
+					<button v-for="(idx, el) of ArrStr" v-on:click="el = el + idx; MapStrStr[el] = StringVarWorld" />
+					<button @click="Shout" />
+					<button @click="Fail" />
+					<button @click="FuncPlus = nil" />
+				</flex>
+			</template>`
+
+	// the fields can only be changed through a pointer to the definition
+	page, err := NewPage(&def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buttons := page.root.Children[0].Children
+	click := func(idx int, args ...interface{}) error {
+		return page.handleEvent(buttons[idx], buttons[idx].TplNode.Events["click"], args)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := click(0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if def.Int8Var2 != 4 || def.StringVarHello != "hello!!" {
+		t.Fatalf("the fields are not changed as expected: %d, %s", def.Int8Var2, def.StringVarHello)
+	}
+
+	// a loop variable only changes for its own node
+	if err := click(2); err != nil {
+		t.Fatal(err)
+	}
+	if buttons[2].Vars["el"] != "world1" || buttons[1].Vars["el"] != "hello" || def.MapStrStr["world1"] != "world" {
+		t.Fatalf("the loop variable is not changed as expected: %v, %v", buttons[2].Vars, def.MapStrStr)
+	}
+
+	// a handler which is the name of a function gets the arguments of the event
+	if err := click(3, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := click(4); !tperr.IsErrorType(err, "calc.funcReturnedError") {
+		t.Fatalf("expect the error calc.funcReturnedError, got: %v", err)
+	}
+	if err := click(5); err != nil || def.FuncPlus != nil {
+		t.Fatalf("the function field is expected to be set to nil, got: %v", err)
+	}
+
+	def.Tpl = `<template><box @nope="Int8Var2++" /></template>`
+	if _, err := NewPage(&def); !tperr.IsErrorType(err, "comp.SetEventHandler.eventNotSupported") {
+		t.Fatalf("expect the error comp.SetEventHandler.eventNotSupported, got: %v", err)
+	}
+}
+
+type VforDef struct {
+	Tpl      string
+	Items    []string
+	Scores   map[string]int
+	Seq      func(yield func(string) bool)
+	Seq2     func(yield func(string, int) bool)
+	Chan     <-chan int
+	MapOrder func(a interface{}, b interface{}) bool
+}
+
+func TestVfor(t *testing.T) {
+	newDef := func() VforDef {
+		ch := make(chan int, 3)
+		ch <- 7
+		ch <- 8
+		close(ch)
+
+		return VforDef{
+			Items:  []string{"a", "b", "c"},
+			Scores: map[string]int{"x": 1, "y": 2},
+			Seq: func(yield func(string) bool) {
+				for _, s := range []string{"p", "q"} {
+					if !yield(s) {
+						return
+					}
+				}
+			},
+			Seq2: func(yield func(string, int) bool) {
+				if yield("k1", 1) {
+					yield("k2", 2)
+				}
+			},
+			Chan: ch,
+		}
+	}
+
+	cases := []struct {
+		vfor   string
+		text   string
+		order  func(a interface{}, b interface{}) bool
+		expect []string
+		err    string
+	}{
+		{vfor: `item of Items`, text: `item`, expect: []string{"a", "b", "c"}},
+		{vfor: `(idx, item) of Items`, text: `idx + item`, expect: []string{"0a", "1b", "2c"}},
+		{vfor: `(item, idx, total) of Items`, text: `sprintf('%s%d/%d', item, idx, total)`, expect: []string{"a0/3", "b1/3", "c2/3"}},
+		{vfor: `n of 3`, text: `n`, expect: []string{"1", "2", "3"}},
+		{vfor: `(idx, n) of 2`, text: `idx + '-' + n`, expect: []string{"0-1", "1-2"}},
+		{vfor: `(k, v) of Scores`, text: `k + v`, expect: []string{"x1", "y2"}},
+		{
+			vfor:   `(k, v) of Scores`,
+			text:   `k + v`,
+			order:  func(a interface{}, b interface{}) bool { return a.(string) > b.(string) },
+			expect: []string{"y2", "x1"},
+		},
+		{vfor: `(v, k, total) of Scores`, text: `sprintf('%s%d/%d', k, v, total)`, expect: []string{"x1/2", "y2/2"}},
+		{vfor: `(idx, s) of Seq`, text: `idx + s`, expect: []string{"0p", "1q"}},
+		{vfor: `(k, v) of Seq2`, text: `k + v`, expect: []string{"k11", "k22"}},
+		{vfor: `n of Chan`, text: `n`, expect: []string{"7", "8"}},
+		{vfor: `n of Tpl`, text: `n`, err: "page.cannotIterateOverTheVar"},
+	}
+
+	for _, testCase := range cases {
+		t.Log(testCase.vfor)
+
+		def := newDef()
+		def.Tpl = `<template>
+					<flex>
+						<textarea v-for="` + testCase.vfor + `" :text="sprintf('%v', ` + testCase.text + `)" />
+					</flex>
+				</template>`
+		def.MapOrder = testCase.order
+
+		page, err := NewPage(def)
+		if testCase.err != "" {
+			if !tperr.IsErrorType(err, testCase.err) {
+				t.Fatalf("expect the error %s, got: %v", testCase.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a render again lists the same items, even those read from the channel, which is drained
+		for i := 0; i < 2; i++ {
+			if i > 0 {
+				if err := page.Render(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			texts := []string{}
+			for _, node := range page.root.Children[0].Children {
+				text, err := node.Comp.GetProp("text")
+				if err != nil {
+					t.Fatal(err)
+				}
+				texts = append(texts, text.(string))
+			}
+			if strings.Join(texts, ",") != strings.Join(testCase.expect, ",") {
+				t.Fatalf("expect: %v, got: %v", testCase.expect, texts)
+			}
+		}
+	}
+}
+
+func TestVforOpenChan(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 7
+	def := VforDef{
+		Tpl: `<template>
+				<flex>
+					<textarea v-for="n of Chan" :text="sprintf('%v', n)" />
+				</flex>
+			</template>`,
+		Chan: ch,
+	}
+
+	// the channel is never closed, so a render must not wait for it
+	page, err := NewPage(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(expect ...string) {
+		texts := []string{}
+		for _, node := range page.root.Children[0].Children {
+			text, err := node.Comp.GetProp("text")
+			if err != nil {
+				t.Fatal(err)
+			}
+			texts = append(texts, text.(string))
+		}
+		if strings.Join(texts, ",") != strings.Join(expect, ",") {
+			t.Fatalf("expect: %v, got: %v", expect, texts)
+		}
+	}
+	check("7")
+
+	// the values sent later are listed by the next render, after those read before
+	ch <- 8
+	ch <- 9
+	if err := page.Render(); err != nil {
+		t.Fatal(err)
+	}
+	check("7", "8", "9")
+
+	if err := page.Render(); err != nil {
+		t.Fatal(err)
+	}
+	check("7", "8", "9")
+
+	ch <- 10
+	close(ch)
+	if err := page.Render(); err != nil {
+		t.Fatal(err)
+	}
+	check("7", "8", "9", "10")
+}
+
+type ShowDef struct {
+	Tpl     string
+	Visible *Ref[bool]
+	Limit   *Ref[int]
+	Items   []string
+}
+
+func TestVshow(t *testing.T) {
+	def := ShowDef{
+		Tpl: `<template>
+				<flex>
+					<button v-show="Visible" />
+					<button v-for="(idx, item) of Items" v-show="idx < Limit" />
+				</flex>
+			</template>`,
+		Visible: NewRef(false),
+		Limit:   NewRef(1),
+		Items:   []string{"a", "b", "c"},
+	}
+
+	page, err := NewPage(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buttons := page.root.Children[0].Children
+	comps := []comp.Component{}
+	for _, node := range buttons {
+		comps = append(comps, node.Comp)
+	}
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(4, 1)
+	// whether the component draws anything, like the background of a button
+	drawn := func(component comp.Component) bool {
+		screen.Clear()
+		primitive := component.(interface{ Primitive() tview.Primitive }).Primitive()
+		primitive.SetRect(0, 0, 4, 1)
+		primitive.Draw(screen)
+		_, _, style, _ := screen.GetContent(0, 0)
+		return style != tcell.StyleDefault
+	}
+
+	check := func(expect ...bool) {
+		for i, node := range buttons {
+			visible, err := node.Comp.GetProp("visible")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !node.HasShow || node.Show != expect[i] || visible != expect[i] {
+				t.Fatalf("button %d is expected to be visible: %v, got: %v", i, expect[i], visible)
+			}
+			if drawn(node.Comp) != expect[i] {
+				t.Fatalf("button %d is expected to be drawn: %v", i, expect[i])
+			}
+			// the component is kept, rather than created again
+			if node.Comp != comps[i] {
+				t.Fatalf("button %d is expected to be kept", i)
+			}
+		}
+	}
+
+	check(false, true, false, false)
+	def.Visible.Set(true)
+	check(true, true, false, false)
+	def.Limit.Set(3)
+	check(true, true, true, true)
+	def.Visible.Set(false)
+	def.Limit.Set(0)
+	check(false, false, false, false)
+
+	def.Tpl = `<template><button v-show="Items" /></template>`
+	if _, err := NewPage(def); !tperr.IsErrorType(err, "page.vshowDirectiveMustBeBool") {
+		t.Fatalf("expect the error page.vshowDirectiveMustBeBool, got: %v", err)
+	}
+}
+
+type MemoRow struct {
+	Title   string
+	Version int
+}
+
+type MemoDef struct {
+	Tpl   string
+	Title *Ref[string]
+	Rows  *Ref[[]MemoRow]
+}
+
+func TestVonceVmemo(t *testing.T) {
+	def := MemoDef{
+		Tpl: `<template>
+				<flex>
+					<textarea v-once :text="Title" />
+					<textarea :text="Title" />
+					<flex v-for="row of Rows" v-memo="[row.Version]">
+						<textarea :text="row.Title" />
+					</flex>
+				</flex>
+			</template>`,
+		Title: NewRef("a"),
+		Rows:  NewRef([]MemoRow{{"x", 1}, {"y", 1}}),
+	}
+
+	page, err := NewPage(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts := func() []string {
+		texts := []string{}
+		nodes := page.root.Children[0].Children
+		for _, node := range nodes {
+			if len(node.Children) > 0 {
+				node = node.Children[0]
+			}
+			text, err := node.Comp.GetProp("text")
+			if err != nil {
+				t.Fatal(err)
+			}
+			texts = append(texts, text.(string))
+		}
+		return texts
+	}
+	check := func(expect ...string) {
+		if got := texts(); strings.Join(got, ",") != strings.Join(expect, ",") {
+			t.Fatalf("expect: %v, got: %v", expect, got)
+		}
+	}
+
+	check("a", "a", "x", "y")
+
+	// the page is rendered again when a Ref is changed, except for the node with v-once
+	def.Title.Set("b")
+	check("a", "b", "x", "y")
+
+	// a row is rendered again only if its version is changed
+	row := page.root.Children[0].Children[2]
+	def.Rows.Set([]MemoRow{{"x2", 1}, {"y2", 2}, {"z", 1}})
+	check("a", "b", "x", "y2", "z")
+	if page.root.Children[0].Children[2] != row {
+		t.Fatal("the node of the unchanged row is expected to be kept")
+	}
+
+	def.Tpl = `<template><flex><textarea v-memo="Title" /></flex></template>`
+	if _, err := NewPage(def); !tperr.IsErrorType(err, "page.vmemoDirectiveMustBeArray") {
+		t.Fatalf("expect the error page.vmemoDirectiveMustBeArray, got: %v", err)
+	}
+}
+
+func benchmarkRenderRows(b *testing.B, memo string) {
+	rows := make([]MemoRow, 10000)
+	for i := range rows {
+		rows[i] = MemoRow{Title: fmt.Sprintf("row %d", i)}
+	}
+	def := MemoDef{
+		Tpl: `<template>
+				<flex>
+					<flex v-for="(idx, row) of Rows" ` + memo + `>
+						<textarea :text="sprintf('%d: %s', idx, row.Title)" />
+						<button :label="row.Title + ' v' + row.Version" />
+					</flex>
+				</flex>
+			</template>`,
+		Rows: NewRef(rows),
+	}
+	page, err := NewPage(def)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := page.Render(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderRows(b *testing.B) {
+	benchmarkRenderRows(b, "")
+}
+
+func BenchmarkRenderRowsMemo(b *testing.B) {
+	benchmarkRenderRows(b, `v-memo="[row.Version]"`)
+}
+
+type DirectiveDef struct {
+	Tpl        string
+	Role       *Ref[string]
+	Shown      *Ref[bool]
+	Directives map[string]*Directive
+}
+
+func TestDirective(t *testing.T) {
+	calls := []string{}
+	record := func(hook string) func(binding *DirectiveBinding) error {
+		return func(binding *DirectiveBinding) error {
+			calls = append(calls, fmt.Sprintf("%s %s:%s %v %v->%v", hook, binding.Name, binding.Arg, binding.Modifiers["ctrl"], binding.OldValue, binding.Value))
+			return nil
+		}
+	}
+
+	if err := RegisterDirective("shortcut", &Directive{Bind: record("bind"), Unbind: record("unbind")}); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterDirective("shortcut")
+	if err := RegisterDirective("if", &Directive{}); !tperr.IsErrorType(err, "page.invalidDirective") {
+		t.Fatalf("expect the error page.invalidDirective, got: %v", err)
+	}
+
+	def := DirectiveDef{
+		Tpl: `<template>
+				<flex>
+					<button v-permission="Role" />
+					<button v-if="Shown" v-shortcut:save.ctrl="'s'" v-shortcut:open="'o'" />
+				</flex>
+			</template>`,
+		Role:  NewRef("guest"),
+		Shown: NewRef(true),
+		Directives: map[string]*Directive{
+			"permission": {
+				Bind:   record("bind"),
+				Update: record("update"),
+				Unbind: record("unbind"),
+			},
+		},
+	}
+
+	if _, err := NewPage(def); err != nil {
+		t.Fatal(err)
+	}
+	def.Role.Set("admin")
+	def.Shown.Set(false)
+
+	expect := []string{
+		"bind permission: false <nil>->guest",
+		"bind shortcut:save true <nil>->s",
+		"bind shortcut:open false <nil>->o",
+		"update permission: false guest->admin",
+		"update permission: false admin->admin",
+		"unbind shortcut:open false o->o",
+		"unbind shortcut:save true s->s",
+	}
+	if strings.Join(calls, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("expect: %v, got: %v", expect, calls)
+	}
+
+	def.Tpl = `<template><flex><button v-nope /></flex></template>`
+	if _, err := NewPage(def); !tperr.IsErrorType(err, "page.directiveNotFound") {
+		t.Fatalf("expect the error page.directiveNotFound, got: %v", err)
+	}
+}
+
+type ReactiveDef struct {
+	Tpl     string
+	Rows    *ReactiveSlice[TodoItem]
+	Renders map[string]int
+}
+
+func (d *ReactiveDef) Label(item TodoItem) string {
+	d.Renders[item.Title] += 1
+	if item.Done {
+		return item.Title + " (done)"
+	}
+	return item.Title
+}
+
+func TestReactiveVfor(t *testing.T) {
+	def := &ReactiveDef{
+		Tpl: `<template>
+				<flex>
+					<textarea v-for="(idx, row) of Rows" :text="Label(row)" />
+				</flex>
+			</template>`,
+		Rows:    NewReactiveSlice([]TodoItem{{Title: "a"}, {Title: "b"}}),
+		Renders: map[string]int{},
+	}
+
+	page, err := NewPage(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(step string, expectTexts []string, expectRenders map[string]int) {
+		texts := []string{}
+		for _, node := range page.root.Children[0].Children {
+			text, _ := node.Comp.GetProp("text")
+			texts = append(texts, text.(string))
+		}
+		if strings.Join(texts, ",") != strings.Join(expectTexts, ",") {
+			t.Fatalf("%s: expect: %v, got: %v", step, expectTexts, texts)
+		}
+		for title, count := range expectRenders {
+			if def.Renders[title] != count {
+				t.Fatalf("%s: the row %s is expected to be rendered %d times, got: %d", step, title, count, def.Renders[title])
+			}
+		}
+	}
+
+	check("init", []string{"a", "b"}, map[string]int{"a": 1, "b": 1})
+
+	// only the changed row is rendered again
+	first := page.root.Children[0].Children[0]
+	def.Rows.Set(1, TodoItem{Title: "b", Done: true})
+	check("set", []string{"a", "b (done)"}, map[string]int{"a": 1, "b": 2})
+	if page.root.Children[0].Children[0] != first {
+		t.Fatal("the node of the unchanged row is expected to be kept")
+	}
+
+	// an added row renders the whole list again
+	def.Rows.Append(TodoItem{Title: "c"})
+	check("append", []string{"a", "b (done)", "c"}, map[string]int{"a": 2, "b": 3, "c": 1})
+	def.Rows.Set(2, TodoItem{Title: "c", Done: true})
+	check("set after append", []string{"a", "b (done)", "c (done)"}, map[string]int{"a": 2, "b": 3, "c": 2})
+}
+
+type ScopeDef struct {
+	Tpl        string
+	Shown      *Ref[bool]
+	Visible    *Ref[bool]
+	Title      *Ref[string]
+	Directives map[string]*Directive
+}
+
+func TestPageScope(t *testing.T) {
+	tick := NewRef(0)
+	ticks := 0
+	unbinds := 0
+	def := ScopeDef{
+		Tpl: `<template>
+				<flex>
+					<textarea v-if="Shown" v-ticker :text="Title" />
+					<flex v-once><button v-show="Visible" /></flex>
+				</flex>
+			</template>`,
+		Shown:   NewRef(true),
+		Visible: NewRef(true),
+		Title:   NewRef("a"),
+		Directives: map[string]*Directive{
+			// a watcher made by Bind lives as long as the component
+			"ticker": {
+				Bind: func(binding *DirectiveBinding) error {
+					RunReactively(func() {
+						tick.Get()
+						ticks += 1
+					})
+					return nil
+				},
+				Unbind: func(binding *DirectiveBinding) error {
+					unbinds += 1
+					return nil
+				},
+			},
+		},
+	}
+
+	page, err := NewPage(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the component is rendered again, which keeps the watcher of its directive
+	def.Title.Set("b")
+	tick.Set(1)
+	if ticks != 2 {
+		t.Fatalf("the watcher of the directive is expected to be kept, got: %d runs", ticks)
+	}
+
+	// the v-show of a node kept by v-once still works after a render
+	def.Visible.Set(false)
+	show := page.root.Children[0].Children[1].Children[0]
+	if visible, _ := show.Comp.GetProp("visible"); visible != false {
+		t.Fatalf("the v-show of the v-once node is expected to work, got: %v", visible)
+	}
+
+	// the watcher is stopped along with the component
+	def.Shown.Set(false)
+	tick.Set(2)
+	if ticks != 2 || unbinds != 1 {
+		t.Fatalf("the watcher of the directive is expected to be stopped, got: %d runs, %d unbinds", ticks, unbinds)
+	}
+
+	// an unmounted page is never rendered again
+	def.Shown.Set(true)
+	tick.Set(3)
+	if err := page.Unmount(); err != nil {
+		t.Fatal(err)
+	}
+	if unbinds != 2 || !page.Scope().isStopped() {
+		t.Fatalf("the directives are expected to be unbound, got: %d unbinds", unbinds)
+	}
+	ticksBefore := ticks
+	def.Title.Set("c")
+	def.Visible.Set(true)
+	tick.Set(4)
+	if ticks != ticksBefore || len(def.Title.watchers) != 0 || len(def.Visible.watchers) != 0 {
+		t.Fatalf("the watchers of the page are expected to be stopped")
+	}
+}
@@ -0,0 +1,538 @@
+package rview
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/TinyWisp/rview/ddl"
+	"github.com/TinyWisp/rview/tperr"
+)
+
+// a part of a reactive container, like an element of a ReactiveSlice, which is watched on its own
+type reactiveDep struct {
+	mutex    sync.Mutex
+	watchers []*Watcher
+}
+
+// the parts of a ReactiveSlice, a ReactiveMap or a ReactiveStruct, which the expressions read and assign one by one,
+// like Rows[1].Done, so that only the watchers reading a part are triggered when it is changed
+type reactiveContainer interface {
+	readPart(key *ddl.Exp) (interface{}, error)
+	assignPart(key *ddl.Exp, val *ddl.Exp) error
+}
+
+// a ReactiveSlice or a ReactiveMap, which can be iterated by v-for.
+// the keys are read with only the structure watched, and each value can be read without being watched,
+// so that a v-for over it reacts to an added or removed item as a whole, and to a changed item on its own.
+type reactiveIterable interface {
+	reactiveContainer
+	Len() int
+	keyed() bool
+	partKeys() []reflect.Value
+	peekPart(key reflect.Value) interface{}
+	getPart(key reflect.Value) interface{}
+}
+
+func (d *reactiveDep) track() {
+	activeWatcher := activeWatcherMgr.ActiveWatcher()
+	if activeWatcher != nil {
+		activeWatcher.AddRef(d)
+		d.AddWatcher(activeWatcher)
+	}
+}
+
+func (d *reactiveDep) AddWatcher(watcher *Watcher) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, owatcher := range d.watchers {
+		if owatcher == watcher {
+			return
+		}
+	}
+
+	d.watchers = append(d.watchers, watcher)
+}
+
+func (d *reactiveDep) RemoveWatcher(watcher *Watcher) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for idx, owatcher := range d.watchers {
+		if owatcher == watcher {
+			d.watchers = append(d.watchers[:idx:idx], d.watchers[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (d *reactiveDep) hasWatchers() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.watchers) > 0
+}
+
+func (d *reactiveDep) Trigger() {
+	triggerDeps(d)
+}
+
+// trigger the watchers of some deps, each of which is triggered once even if it watches several of them
+func triggerDeps(deps ...*reactiveDep) {
+	watchers := []*Watcher{}
+	triggered := map[*Watcher]bool{}
+	for _, dep := range deps {
+		dep.mutex.Lock()
+		for _, watcher := range dep.watchers {
+			if !triggered[watcher] {
+				triggered[watcher] = true
+				watchers = append(watchers, watcher)
+			}
+		}
+		dep.mutex.Unlock()
+	}
+
+	triggerWatchers(watchers, deps[0], nil, nil)
+}
+
+// a slice whose elements are watched one by one, while a Ref[[]T] is only triggered when the whole slice is replaced.
+// a watcher reading Get(1) is triggered by Set(1, ...), but not by Set(2, ...),
+// and a watcher reading Len or Values is triggered by Append and Delete as well.
+// like a Ref, it can be used by several goroutines, and the watchers are triggered out of the lock.
+type ReactiveSlice[T any] struct {
+	mutex  sync.RWMutex
+	items  []T
+	deps   []*reactiveDep
+	lenDep *reactiveDep
+}
+
+func NewReactiveSlice[T any](items []T) *ReactiveSlice[T] {
+	r := &ReactiveSlice[T]{
+		items:  append([]T{}, items...),
+		deps:   make([]*reactiveDep, len(items)),
+		lenDep: &reactiveDep{},
+	}
+	for i := range r.deps {
+		r.deps[i] = &reactiveDep{}
+	}
+
+	return r
+}
+
+// get an element, which panics if the index is out of range, like a slice
+func (r *ReactiveSlice[T]) Get(idx int) T {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	r.deps[idx].track()
+	return r.items[idx]
+}
+
+func (r *ReactiveSlice[T]) Set(idx int, val T) {
+	r.mutex.Lock()
+	old := r.items[idx]
+	r.items[idx] = val
+	dep := r.deps[idx]
+	r.mutex.Unlock()
+
+	if !isSameValue(old, val) {
+		dep.Trigger()
+	}
+}
+
+func (r *ReactiveSlice[T]) Len() int {
+	r.lenDep.track()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.items)
+}
+
+func (r *ReactiveSlice[T]) Append(vals ...T) {
+	if len(vals) == 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	r.items = append(r.items, vals...)
+	for range vals {
+		r.deps = append(r.deps, &reactiveDep{})
+	}
+	r.mutex.Unlock()
+
+	r.lenDep.Trigger()
+}
+
+// remove an element, which moves the elements after it forward, so they are all triggered
+func (r *ReactiveSlice[T]) Delete(idx int) {
+	r.mutex.Lock()
+	r.items = append(r.items[:idx], r.items[idx+1:]...)
+	moved := r.deps[idx:]
+	r.deps = append(append([]*reactiveDep{}, r.deps[:idx]...), r.deps[idx+1:]...)
+	r.mutex.Unlock()
+
+	triggerDeps(append([]*reactiveDep{r.lenDep}, moved...)...)
+}
+
+// a copy of all the elements, which watches all of them
+func (r *ReactiveSlice[T]) Values() []T {
+	r.lenDep.track()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, dep := range r.deps {
+		dep.track()
+	}
+
+	return append([]T{}, r.items...)
+}
+
+func (r *ReactiveSlice[T]) readPart(key *ddl.Exp) (interface{}, error) {
+	if key.Type != ddl.ExpInt {
+		return nil, tperr.NewTypedError("calc.operandTypeMismatch", "[]", reflect.TypeOf(r).String(), key.ActualTypeName())
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if key.Int < 0 || key.Int >= int64(len(r.items)) {
+		return nil, tperr.NewTypedError("calc.indexOutOfRange", key.Int, len(r.items))
+	}
+
+	r.deps[key.Int].track()
+	return r.items[key.Int], nil
+}
+
+func (r *ReactiveSlice[T]) assignPart(key *ddl.Exp, val *ddl.Exp) error {
+	if key.Type != ddl.ExpInt {
+		return tperr.NewTypedError("calc.operandTypeMismatch", "[]", reflect.TypeOf(r).String(), key.ActualTypeName())
+	}
+	elemType := reflect.TypeOf((*T)(nil)).Elem()
+	elem, ok := expToTypedValue(val, elemType)
+	if !ok {
+		return tperr.NewTypedError("calc.assignmentTypeMismatch", val.ActualTypeName(), elemType.String())
+	}
+
+	r.mutex.Lock()
+	if key.Int < 0 || key.Int >= int64(len(r.items)) {
+		count := len(r.items)
+		r.mutex.Unlock()
+		return tperr.NewTypedError("calc.indexOutOfRange", key.Int, count)
+	}
+	idx := int(key.Int)
+	old, newVal := r.items[idx], elem.Interface().(T)
+	r.items[idx] = newVal
+	dep := r.deps[idx]
+	r.mutex.Unlock()
+
+	if !isSameValue(old, newVal) {
+		dep.Trigger()
+	}
+	return nil
+}
+
+func (r *ReactiveSlice[T]) keyed() bool {
+	return false
+}
+
+func (r *ReactiveSlice[T]) partKeys() []reflect.Value {
+	r.lenDep.track()
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	keys := make([]reflect.Value, len(r.items))
+	for i := range keys {
+		keys[i] = reflect.ValueOf(i)
+	}
+
+	return keys
+}
+
+func (r *ReactiveSlice[T]) peekPart(key reflect.Value) interface{} {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.items[key.Int()]
+}
+
+func (r *ReactiveSlice[T]) getPart(key reflect.Value) interface{} {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	idx := int(key.Int())
+	if idx >= len(r.items) {
+		r.lenDep.track()
+		return nil
+	}
+
+	r.deps[idx].track()
+	return r.items[idx]
+}
+
+// a map whose entries are watched one by one, while a Ref[map[K]V] is only triggered when the whole map is replaced.
+// a watcher reading Get(k) is triggered when the entry of k is set or deleted,
+// and a watcher reading a missing key, Len or Keys is triggered when an entry is added or deleted.
+type ReactiveMap[K comparable, V any] struct {
+	mutex   sync.Mutex
+	items   map[K]V
+	deps    map[K]*reactiveDep
+	keysDep *reactiveDep
+}
+
+func NewReactiveMap[K comparable, V any](items map[K]V) *ReactiveMap[K, V] {
+	r := &ReactiveMap[K, V]{
+		items:   make(map[K]V, len(items)),
+		deps:    map[K]*reactiveDep{},
+		keysDep: &reactiveDep{},
+	}
+	for k, v := range items {
+		r.items[k] = v
+	}
+
+	return r
+}
+
+// the dep of an existing key, which is made when the key is first read.
+// a missing key has no dep of its own, and reading it is watched by the keys dep, so that the missing keys don't pile up.
+// it is called with the mutex locked.
+func (r *ReactiveMap[K, V]) dep(key K) *reactiveDep {
+	if _, ok := r.items[key]; !ok {
+		return r.keysDep
+	}
+
+	dep, ok := r.deps[key]
+	if !ok {
+		dep = &reactiveDep{}
+		r.deps[key] = dep
+	}
+
+	return dep
+}
+
+func (r *ReactiveMap[K, V]) Get(key K) (V, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.dep(key).track()
+	val, ok := r.items[key]
+	return val, ok
+}
+
+func (r *ReactiveMap[K, V]) Set(key K, val V) {
+	r.mutex.Lock()
+	old, ok := r.items[key]
+	r.items[key] = val
+	// the dep of a key which has never been read is nil, as nothing watches it
+	dep := r.deps[key]
+	r.mutex.Unlock()
+
+	if !ok {
+		if dep != nil {
+			triggerDeps(r.keysDep, dep)
+		} else {
+			r.keysDep.Trigger()
+		}
+	} else if dep != nil && !isSameValue(old, val) {
+		dep.Trigger()
+	}
+}
+
+// delete an entry, and the dep of its key as well if nothing watches it after the watchers are triggered
+func (r *ReactiveMap[K, V]) Delete(key K) {
+	r.mutex.Lock()
+	if _, ok := r.items[key]; !ok {
+		r.mutex.Unlock()
+		return
+	}
+	delete(r.items, key)
+	dep := r.deps[key]
+	r.mutex.Unlock()
+
+	if dep == nil {
+		r.keysDep.Trigger()
+		return
+	}
+	triggerDeps(r.keysDep, dep)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.items[key]; !ok && r.deps[key] == dep && !dep.hasWatchers() {
+		delete(r.deps, key)
+	}
+}
+
+func (r *ReactiveMap[K, V]) Len() int {
+	r.keysDep.track()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.items)
+}
+
+// the keys, in no particular order
+func (r *ReactiveMap[K, V]) Keys() []K {
+	r.keysDep.track()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	keys := make([]K, 0, len(r.items))
+	for k := range r.items {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func (r *ReactiveMap[K, V]) readPart(key *ddl.Exp) (interface{}, error) {
+	keyType := reflect.TypeOf(r.items).Key()
+	mapKey, ok := expToTypedValue(key, keyType)
+	if !ok {
+		return nil, tperr.NewTypedError("calc.operandTypeMismatch", "[]", reflect.TypeOf(r).String(), key.ActualTypeName())
+	}
+
+	val, ok := r.Get(mapKey.Interface().(K))
+	if !ok {
+		return nil, nil
+	}
+	return val, nil
+}
+
+func (r *ReactiveMap[K, V]) assignPart(key *ddl.Exp, val *ddl.Exp) error {
+	mapType := reflect.TypeOf(r.items)
+	mapKey, ok := expToTypedValue(key, mapType.Key())
+	if !ok {
+		return tperr.NewTypedError("calc.assignmentTypeMismatch", key.ActualTypeName(), mapType.Key().String())
+	}
+	mapVal, ok := expToTypedValue(val, mapType.Elem())
+	if !ok {
+		return tperr.NewTypedError("calc.assignmentTypeMismatch", val.ActualTypeName(), mapType.Elem().String())
+	}
+
+	r.Set(mapKey.Interface().(K), mapVal.Interface().(V))
+	return nil
+}
+
+func (r *ReactiveMap[K, V]) keyed() bool {
+	return true
+}
+
+func (r *ReactiveMap[K, V]) partKeys() []reflect.Value {
+	r.keysDep.track()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	keys := make([]reflect.Value, 0, len(r.items))
+	for k := range r.items {
+		keys = append(keys, reflect.ValueOf(k))
+	}
+
+	return keys
+}
+
+func (r *ReactiveMap[K, V]) peekPart(key reflect.Value) interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.items[key.Interface().(K)]
+}
+
+func (r *ReactiveMap[K, V]) getPart(key reflect.Value) interface{} {
+	val, _ := r.Get(key.Interface().(K))
+	return val
+}
+
+// a struct whose fields are watched one by one, while a Ref[T] is only triggered when the whole struct is replaced.
+// a watcher reading Get("Name") is triggered by Set("Name", ...), but not by Set("Age", ...).
+type ReactiveStruct[T any] struct {
+	mutex    sync.Mutex
+	value    T
+	deps     map[string]*reactiveDep
+	valueDep *reactiveDep
+}
+
+func NewReactiveStruct[T any](value T) *ReactiveStruct[T] {
+	return &ReactiveStruct[T]{
+		value:    value,
+		deps:     map[string]*reactiveDep{},
+		valueDep: &reactiveDep{},
+	}
+}
+
+func (r *ReactiveStruct[T]) dep(field string) *reactiveDep {
+	dep, ok := r.deps[field]
+	if !ok {
+		dep = &reactiveDep{}
+		r.deps[field] = dep
+	}
+
+	return dep
+}
+
+func (r *ReactiveStruct[T]) Get(field string) (interface{}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	val, err := GetStructField(&r.value, field)
+	if err != nil {
+		return nil, err
+	}
+
+	r.dep(field).track()
+	return val, nil
+}
+
+func (r *ReactiveStruct[T]) Set(field string, val interface{}) error {
+	return r.set(field, func() error {
+		return SetStructField(&r.value, field, val)
+	})
+}
+
+// a copy of the struct, which watches all the fields
+func (r *ReactiveStruct[T]) Value() T {
+	r.valueDep.track()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.value
+}
+
+// the value of a field as it is, without watching it, or nil if there is no such field.
+// it is called with the mutex locked.
+func (r *ReactiveStruct[T]) peekField(field string) interface{} {
+	index := structFieldIndex(reflect.TypeOf(r.value), field)
+	if index == nil {
+		return nil
+	}
+	fieldVal, err := reflect.ValueOf(r.value).FieldByIndexErr(index)
+	if err != nil || !fieldVal.CanInterface() {
+		return nil
+	}
+
+	return fieldVal.Interface()
+}
+
+// change a field by fn with the mutex locked, and then trigger the watchers of the field if it is changed
+func (r *ReactiveStruct[T]) set(field string, fn func() error) error {
+	r.mutex.Lock()
+	old := r.peekField(field)
+	if err := fn(); err != nil {
+		r.mutex.Unlock()
+		return err
+	}
+	changed := !isSameValue(old, r.peekField(field))
+	dep := r.dep(field)
+	r.mutex.Unlock()
+
+	if changed {
+		triggerDeps(dep, r.valueDep)
+	}
+	return nil
+}
+
+func (r *ReactiveStruct[T]) readPart(key *ddl.Exp) (interface{}, error) {
+	if key.Type != ddl.ExpStr {
+		return nil, tperr.NewTypedError("calc.operandTypeMismatch", ".", reflect.TypeOf(r).String(), key.ActualTypeName())
+	}
+
+	return r.Get(key.Str)
+}
+
+func (r *ReactiveStruct[T]) assignPart(key *ddl.Exp, val *ddl.Exp) error {
+	if key.Type != ddl.ExpStr {
+		return tperr.NewTypedError("calc.operandTypeMismatch", ".", reflect.TypeOf(r).String(), key.ActualTypeName())
+	}
+
+	return r.set(key.Str, func() error {
+		return AssignStructField(&r.value, key.Str, val)
+	})
+}
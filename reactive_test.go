@@ -0,0 +1,172 @@
+package rview
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TinyWisp/rview/ddl"
+)
+
+func TestReactiveSlice(t *testing.T) {
+	s := NewReactiveSlice([]string{"a", "b", "c"})
+
+	runs := map[string]int{}
+	stops := []func(){
+		RunReactively(func() { s.Get(0); runs["0"] += 1 }),
+		RunReactively(func() { s.Get(1); runs["1"] += 1 }),
+		RunReactively(func() { s.Len(); runs["len"] += 1 }),
+		RunReactively(func() { s.Values(); runs["values"] += 1 }),
+	}
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+
+	check := func(step string, expect map[string]int) {
+		for name, count := range expect {
+			if runs[name] != count {
+				t.Fatalf("%s: the watcher of %s is expected to run %d times, got: %d", step, name, count, runs[name])
+			}
+		}
+	}
+
+	check("init", map[string]int{"0": 1, "1": 1, "len": 1, "values": 1})
+	s.Set(1, "b2")
+	check("set 1", map[string]int{"0": 1, "1": 2, "len": 1, "values": 2})
+	s.Set(1, "b2")
+	check("set 1 again", map[string]int{"0": 1, "1": 2, "len": 1, "values": 2})
+	s.Append("d")
+	check("append", map[string]int{"0": 1, "1": 2, "len": 2, "values": 3})
+	s.Delete(0)
+	check("delete 0", map[string]int{"0": 2, "1": 3, "len": 3})
+
+	if vals := s.Values(); len(vals) != 3 || vals[0] != "b2" || vals[2] != "d" {
+		t.Fatalf("unexpected values: %v", vals)
+	}
+}
+
+func TestReactiveMap(t *testing.T) {
+	m := NewReactiveMap(map[string]int{"a": 1})
+
+	runs := map[string]int{}
+	stops := []func(){
+		RunReactively(func() { m.Get("a"); runs["a"] += 1 }),
+		RunReactively(func() { m.Get("b"); runs["b"] += 1 }),
+		RunReactively(func() { m.Keys(); runs["keys"] += 1 }),
+	}
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+
+	m.Set("a", 2)
+	m.Set("a", 2)
+	m.Set("b", 1)
+	m.Delete("a")
+	m.Delete("c")
+
+	expect := map[string]int{"a": 3, "b": 2, "keys": 3}
+	for name, count := range expect {
+		if runs[name] != count {
+			t.Fatalf("the watcher of %s is expected to run %d times, got: %d", name, count, runs[name])
+		}
+	}
+	if _, ok := m.Get("a"); ok || m.Len() != 1 {
+		t.Fatalf("the key a is expected to be deleted")
+	}
+
+	// the missing keys share the keys dep, and the dep of a deleted key is dropped once nothing watches it
+	stop := RunReactively(func() {
+		for i := 0; i < 100; i++ {
+			m.Get(fmt.Sprintf("missing%d", i))
+		}
+	})
+	defer stop()
+	m.Delete("b")
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.deps) != 0 {
+		t.Fatalf("no dep is expected to be kept, got: %d", len(m.deps))
+	}
+}
+
+func TestReactiveStruct(t *testing.T) {
+	s := NewReactiveStruct(TodoItem{Title: "a"})
+
+	runs := map[string]int{}
+	stops := []func(){
+		RunReactively(func() { s.Get("Title"); runs["Title"] += 1 }),
+		RunReactively(func() { s.Get("Done"); runs["Done"] += 1 }),
+		RunReactively(func() { s.Value(); runs["value"] += 1 }),
+	}
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+
+	if err := s.Set("Title", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("Title", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("Missing", 1); err == nil {
+		t.Fatal("expect an error when setting a missing field")
+	}
+
+	expect := map[string]int{"Title": 2, "Done": 1, "value": 2}
+	for name, count := range expect {
+		if runs[name] != count {
+			t.Fatalf("the watcher of %s is expected to run %d times, got: %d", name, count, runs[name])
+		}
+	}
+}
+
+func TestReactiveExp(t *testing.T) {
+	rows := NewReactiveSlice([]TodoItem{{Title: "a"}, {Title: "b"}})
+	tags := NewReactiveMap(map[string]string{"color": "red"})
+	todo := NewReactiveStruct(TodoItem{Title: "todo"})
+	vars := map[string]interface{}{
+		"rows": rows,
+		"tags": tags,
+		"todo": todo,
+	}
+	getVar := func(name string) (interface{}, error) {
+		return vars[name], nil
+	}
+	setVar := func(name string, val *ddl.Exp) error {
+		vars[name] = expToValue(val)
+		return nil
+	}
+
+	runs := 0
+	stop := RunReactively(func() {
+		exp, _ := ddl.ParseExp(`rows[1].Title + tags.color + todo.Title + rows.Len()`)
+		if _, err := CalcExp(exp, getVar); err != nil {
+			t.Fatal(err)
+		}
+		runs += 1
+	})
+	defer stop()
+
+	// a change of a part which the expression doesn't read triggers nothing
+	for _, str := range []string{`rows[0].Done = true`, `tags.size = "L"`, `todo.Done = true`, `rows[1].Done = true`, `todo.Title = "x"`, `tags["color"] = "blue"`} {
+		stmt, err := ddl.ParseStmt(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ExecStmt(stmt, getVar, setVar); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if runs != 4 {
+		t.Fatalf("the expression is expected to be evaluated 4 times, got: %d", runs)
+	}
+	if !rows.Get(0).Done || !rows.Get(1).Done || todo.Value().Title != "x" {
+		t.Fatalf("the parts are not changed as expected")
+	}
+}
@@ -1,25 +1,46 @@
 package rview
 
 import (
+	"bytes"
 	"reflect"
+	"runtime"
+	"strconv"
 	"sync"
+	"sync/atomic"
 )
 
+// the Refs and the watchers can be used by several goroutines, like a poller setting a Ref while the UI reads it.
+// a watcher tracks the Refs read by its own goroutine only, and runs on the goroutine of the Ref.Set triggering it,
+// unless there is a scheduler, with which it runs on the goroutine calling Flush, like the event loop of a tview application.
+// so a page whose Refs are set by other goroutines should have a scheduler, see NewAppScheduler.
 type Watcher struct {
+	id          uint64
 	refs        []Watchable
+	refsMutex   sync.Mutex
 	mutex       *sync.Mutex
 	watchWhat   func()
 	doSomething func(Watchable, interface{}, interface{})
+
+	// only a Computed has it, which marks the Computed dirty as soon as one of its dependencies is changed
+	markDirty func()
+
+	// run as soon as it is triggered, rather than being queued by the scheduler, see FlushSync
+	flushSync bool
 }
 
+// the watchers running on each goroutine, the innermost last
 type ActiveWatcherMgr struct {
-	watchers []*Watcher
+	mutex    sync.Mutex
+	watchers map[uint64][]*Watcher
+	depth    atomic.Int64
 }
 
 type Ref[T any] struct {
+	mutex    sync.RWMutex
 	value    T
 	oldValue T
 	watchers []*Watcher
+	computed *computed[T]
 }
 
 type Watchable interface {
@@ -28,39 +49,92 @@ type Watchable interface {
 	Trigger()
 }
 
-var activeWatcherMgr *ActiveWatcherMgr = &ActiveWatcherMgr{}
+var activeWatcherMgr *ActiveWatcherMgr = &ActiveWatcherMgr{
+	watchers: map[uint64][]*Watcher{},
+}
+
+// the number of the watchers ever created, which gives each watcher an id in the order of creation
+var watcherCount atomic.Uint64
+
+// the id of the current goroutine, read from the head of its stack trace, like "goroutine 18 [running]:"
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if idx := bytes.IndexByte(buf, ' '); idx >= 0 {
+		buf = buf[:idx]
+	}
+
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
 
 func (awm *ActiveWatcherMgr) Push(watcher *Watcher) {
-	awm.watchers = append(awm.watchers, watcher)
+	gid := goroutineID()
+
+	awm.mutex.Lock()
+	defer awm.mutex.Unlock()
+	awm.watchers[gid] = append(awm.watchers[gid], watcher)
+	awm.depth.Add(1)
 }
 
 func (awm *ActiveWatcherMgr) Pop() *Watcher {
-	count := len(awm.watchers)
+	gid := goroutineID()
+
+	awm.mutex.Lock()
+	defer awm.mutex.Unlock()
+	watchers := awm.watchers[gid]
+	count := len(watchers)
 	if count == 0 {
 		return nil
 	}
 
-	watcher := awm.watchers[count-1]
-	awm.watchers = awm.watchers[:count-1]
+	watcher := watchers[count-1]
+	if count == 1 {
+		delete(awm.watchers, gid)
+	} else {
+		awm.watchers[gid] = watchers[:count-1]
+	}
+	awm.depth.Add(-1)
 
 	return watcher
 }
 
+// the innermost watcher running on the current goroutine
 func (awm *ActiveWatcherMgr) ActiveWatcher() *Watcher {
-	count := len(awm.watchers)
-	if count == 0 {
+	// no watcher is running on any goroutine, which is the usual case out of the watchers
+	if awm.depth.Load() == 0 {
+		return nil
+	}
+
+	gid := goroutineID()
+
+	awm.mutex.Lock()
+	defer awm.mutex.Unlock()
+	watchers := awm.watchers[gid]
+	if len(watchers) == 0 {
 		return nil
 	}
 
-	return awm.watchers[count-1]
+	return watchers[len(watchers)-1]
 }
 
+// the number of the watchers running on the current goroutine
 func (awm *ActiveWatcherMgr) Depth() int {
-	return len(awm.watchers)
+	if awm.depth.Load() == 0 {
+		return 0
+	}
+
+	gid := goroutineID()
+
+	awm.mutex.Lock()
+	defer awm.mutex.Unlock()
+	return len(awm.watchers[gid])
 }
 
 func NewWatcher(watchWhat func(), doSomething func(Watchable, interface{}, interface{})) *Watcher {
 	watcher := &Watcher{
+		id:          watcherCount.Add(1),
 		refs:        make([]Watchable, 0),
 		watchWhat:   watchWhat,
 		mutex:       &sync.Mutex{},
@@ -82,18 +156,20 @@ func (t *Watcher) RunAndWatch() {
 }
 
 func (t *Watcher) AddRef(ref Watchable) {
+	t.refsMutex.Lock()
+	defer t.refsMutex.Unlock()
 	t.refs = append(t.refs, ref)
 }
 
 func (t *Watcher) clean() {
-	if len(t.refs) == 0 {
-		return
-	}
+	t.refsMutex.Lock()
+	refs := t.refs
+	t.refs = nil
+	t.refsMutex.Unlock()
 
-	for _, ref := range t.refs {
+	for _, ref := range refs {
 		ref.RemoveWatcher(t)
 	}
-	t.refs = t.refs[:0]
 }
 
 func NewRef[T any](t T) *Ref[T] {
@@ -104,27 +180,46 @@ func NewRef[T any](t T) *Ref[T] {
 }
 
 func (r *Ref[T]) Get() T {
+	if r.computed != nil {
+		r.computed.refresh()
+	}
+
 	activeWatcher := activeWatcherMgr.ActiveWatcher()
 	if activeWatcher != nil {
 		activeWatcher.AddRef(r)
 		r.AddWatcher(activeWatcher)
 	}
 
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 	return r.value
 }
 
 func (r *Ref[T]) Set(val T) {
-	r.oldValue = r.value
+	r.mutex.Lock()
+	oldVal := r.value
+	r.oldValue = oldVal
 	r.value = val
+	changed := !r.isEqual(oldVal, val)
+	watchers := append([]*Watcher{}, r.watchers...)
+	r.mutex.Unlock()
 
-	if !r.isEqual(r.oldValue, r.value) {
-		r.Trigger()
+	if changed {
+		r.trigger(watchers, val, oldVal)
 	}
 }
 
 func (r *Ref[T]) isEqual(a T, b T) bool {
+	return isSameValue(a, b)
+}
+
+// whether a change from a to b is not a change, which triggers no watchers
+func isSameValue(a interface{}, b interface{}) bool {
 	aval := reflect.ValueOf(a)
 	bval := reflect.ValueOf(b)
+	if !aval.IsValid() || !bval.IsValid() {
+		return aval.IsValid() == bval.IsValid()
+	}
 
 	if aval.Comparable() {
 		return aval.Equal(bval)
@@ -138,10 +233,14 @@ func (r *Ref[T]) isRef() bool {
 }
 
 func (r *Ref[T]) Type() reflect.Type {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 	return reflect.TypeOf(r.value)
 }
 
 func (r *Ref[T]) AddWatcher(watcher *Watcher) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	for _, owatcher := range r.watchers {
 		if owatcher == watcher {
 			return
@@ -152,17 +251,59 @@ func (r *Ref[T]) AddWatcher(watcher *Watcher) {
 }
 
 func (r *Ref[T]) RemoveWatcher(watcher *Watcher) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	for idx, owatcher := range r.watchers {
 		if owatcher == watcher {
-			r.watchers = append(r.watchers[:idx], r.watchers[idx+1:]...)
+			r.watchers = append(r.watchers[:idx:idx], r.watchers[idx+1:]...)
+			return
 		}
 	}
 }
 
 func (r *Ref[T]) Trigger() {
-	for _, watcher := range r.watchers {
-		watcher.doSomething(r, r.value, r.oldValue)
+	// a watcher may watch the ref again while it is triggered, which changes r.watchers
+	r.mutex.RLock()
+	watchers := append([]*Watcher{}, r.watchers...)
+	newVal, oldVal := r.value, r.oldValue
+	r.mutex.RUnlock()
+
+	r.trigger(watchers, newVal, oldVal)
+}
+
+func (r *Ref[T]) trigger(watchers []*Watcher, newVal T, oldVal T) {
+	triggerWatchers(watchers, r, newVal, oldVal)
+}
+
+// run the triggered watchers.
+// if some of them are of a Computed, all the Computeds depending on the change are marked dirty before any watcher runs,
+// and the watchers are run in a Batch, so that none of them sees a Computed which is not updated yet.
+func triggerWatchers(watchers []*Watcher, ref Watchable, newVal interface{}, oldVal interface{}) {
+	lazy := false
+	for _, watcher := range watchers {
+		if watcher.markDirty != nil {
+			lazy = true
+			break
+		}
 	}
+
+	if !lazy {
+		for _, watcher := range watchers {
+			runWatcher(watcher, ref, newVal, oldVal)
+		}
+		return
+	}
+
+	Batch(func() {
+		for _, watcher := range watchers {
+			if watcher.markDirty != nil {
+				watcher.markDirty()
+			}
+		}
+		for _, watcher := range watchers {
+			runWatcher(watcher, ref, newVal, oldVal)
+		}
+	})
 }
 
 func isRef(v interface{}) bool {
@@ -170,89 +311,57 @@ func isRef(v interface{}) bool {
 	return ok
 }
 
+// watch a Ref, and call fnOnChanged with the new value and the old one when it is changed, see Watch for more options
 func WatchRef[T any](ref *Ref[T], fnOnChanged func(newVal, oldVal T), immediate bool) func() {
-	watcher := NewWatcher(func() {
-		ref.Get()
-	}, func(ref Watchable, newValIntf interface{}, oldValIntf interface{}) {
-		fnOnChanged(newValIntf.(T), oldValIntf.(T))
-	})
-
-	if immediate {
-		ref.Trigger()
-	}
-
-	return func() {
-		ref.RemoveWatcher(watcher)
-	}
+	return Watch(ref, func(newVal T, oldVal T, onCleanup func(cleanup func())) {
+		fnOnChanged(newVal, oldVal)
+	}, WatchOptions{Immediate: immediate})
 }
 
+// watch several Refs, and call fnOnChanged when any of them is changed, see WatchSources for their values
 func WatchRefs(refs []Watchable, fnOnChanged func(), immediate bool) func() {
-	watcher := NewWatcher(func() {
-		for _, ref := range refs {
-			refVal := reflect.ValueOf(ref)
-			refVal.MethodByName("Get").Call([]reflect.Value{})
-		}
-	}, func(ref Watchable, newValIntf interface{}, oldValIntf interface{}) {
-		fnOnChanged()
-	})
-
-	if immediate {
+	return WatchSources(refs, func(newVals []interface{}, oldVals []interface{}, onCleanup func(cleanup func())) {
 		fnOnChanged()
-	}
-
-	return func() {
-		for _, ref := range refs {
-			ref.RemoveWatcher(watcher)
-		}
-	}
+	}, WatchOptions{Immediate: immediate})
 }
 
 func RunAndWatch(runWhat func(), fnOnChanged func(watcher *Watcher)) func() {
-	stopped := false
+	return recordEffect(runAndWatch(runWhat, fnOnChanged))
+}
+
+// RunAndWatch without the EffectScope, for the watchers whose stop funcs are collected by the caller
+func runAndWatch(runWhat func(), fnOnChanged func(watcher *Watcher)) func() {
+	// it may be stopped on one goroutine while triggered on another one
+	stopped := atomic.Bool{}
 
 	watcher := (*Watcher)(nil)
 	watcher = NewWatcher(runWhat, func(ref Watchable, newVal interface{}, oldVal interface{}) {
-		if !stopped {
+		if !stopped.Load() {
 			fnOnChanged(watcher)
 		}
 	})
 
 	return func() {
-		stopped = true
+		stopped.Store(true)
 		watcher.clean()
 	}
 }
 
 func RunReactively(runWhat func()) func() {
-	stopped := false
+	stopped := atomic.Bool{}
 
 	watcher := (*Watcher)(nil)
 	watcher = NewWatcher(runWhat, func(ref Watchable, newVal interface{}, oldVal interface{}) {
-		if !stopped {
+		if !stopped.Load() {
 			watcher.RunAndWatch()
 		}
-		if stopped {
+		if stopped.Load() {
 			watcher.clean()
 		}
 	})
 
-	return func() {
-		stopped = true
+	return recordEffect(func() {
+		stopped.Store(true)
 		watcher.clean()
-	}
-}
-
-func Computed[T any](fnCompute func() T) *Ref[T] {
-	res := NewRef[T](*new(T))
-
-	fnComputeWrapper := func() {
-		res.Set(fnCompute())
-	}
-
-	watcher := (*Watcher)(nil)
-	watcher = NewWatcher(fnComputeWrapper, func(ref Watchable, newVal interface{}, oldVal interface{}) {
-		watcher.RunAndWatch()
 	})
-
-	return res
 }
@@ -1,6 +1,7 @@
 package rview
 
 import (
+	"sync"
 	"testing"
 )
 
@@ -489,3 +490,91 @@ func TestIsRef(t *testing.T) {
 		t.Fatalf("3. isRef doesn't work as expected.")
 	}
 }
+
+func TestConcurrentWatchers(t *testing.T) {
+	// each goroutine tracks the Refs read by its own watchers, even if they run at the same time
+	shared := NewRef[int](0)
+	refs := make([]*Ref[int], 8)
+	runs := make([]int, len(refs))
+	stops := make([]func(), len(refs))
+	wg := sync.WaitGroup{}
+	for i := range refs {
+		refs[i] = NewRef[int](0)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stops[i] = RunReactively(func() {
+				refs[i].Get()
+				runs[i] += 1
+			})
+			for j := 0; j < 100; j++ {
+				shared.Set(shared.Get() + 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+
+	if len(shared.watchers) != 0 {
+		t.Fatalf("no watcher is expected to watch the shared Ref, got: %d", len(shared.watchers))
+	}
+	for i, ref := range refs {
+		if len(ref.watchers) != 1 {
+			t.Fatalf("the Ref #%d is expected to be watched by its own watcher only, got: %d", i, len(ref.watchers))
+		}
+	}
+
+	// a Ref read on another goroutine while a watcher is running isn't watched by it, nor is a Computed refreshed there
+	pollerRef := NewRef(0)
+	doubled := Computed(func() int { return pollerRef.Get() * 2 })
+	effectRuns := 0
+	stopEffect := RunReactively(func() {
+		effectRuns += 1
+		if effectRuns > 1 {
+			return
+		}
+		done := make(chan bool)
+		go func() {
+			pollerRef.Get()
+			doubled.Get()
+			done <- true
+		}()
+		<-done
+	})
+	defer stopEffect()
+	pollerRef.Set(1)
+	if effectRuns != 1 || len(pollerRef.watchers) != 1 || doubled.Get() != 2 {
+		t.Fatalf("the watcher is expected to watch its own Refs only, got: %d runs, %d watchers", effectRuns, len(pollerRef.watchers))
+	}
+
+	// the Refs are set on several goroutines, while the watchers run on the goroutine calling Flush
+	flushes := make(chan func(), 100)
+	SetScheduler(NewScheduler(func(flush func()) {
+		flushes <- flush
+	}))
+	defer SetScheduler(nil)
+
+	for i := range refs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 1; j <= 100; j++ {
+				refs[i].Set(j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for len(flushes) > 0 {
+		(<-flushes)()
+	}
+	for i, ref := range refs {
+		if ref.Get() != 100 || runs[i] < 2 {
+			t.Fatalf("the watcher of the Ref #%d is expected to run in a flush, got: %d runs", i, runs[i])
+		}
+	}
+}
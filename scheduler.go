@@ -0,0 +1,291 @@
+package rview
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TinyWisp/rview/tperr"
+	"github.com/rivo/tview"
+)
+
+// a watcher which keeps triggering itself is run at most this many times in a flush,
+// and then the error scheduler.recursiveUpdate is reported
+const maxWatcherRunsPerFlush = 100
+
+// a scheduler queues the triggered watchers instead of running them at once, and runs them in a flush,
+// where each of them runs once however many of the Refs it watches are changed,
+// so that changing several Refs neither runs a watcher several times, nor shows a state between the changes.
+// the Refs can be changed on any goroutine, while the watchers only run on the goroutine calling Flush.
+type Scheduler struct {
+	mutex        sync.Mutex
+	post         func(flush func())
+	queue        []*queuedWatcher
+	queued       map[*Watcher]*queuedWatcher
+	ticks        []func()
+	posted       bool
+	flushing     bool
+	errorHandler func(err error)
+}
+
+// a watcher waiting for a flush, with the value of the first trigger as the old value, and that of the last one as the new value
+type queuedWatcher struct {
+	watcher *Watcher
+	ref     Watchable
+	newVal  interface{}
+	oldVal  interface{}
+}
+
+var (
+	// the scheduler in use. the watchers are run as soon as they are triggered if it is nil, except in a Batch.
+	scheduler atomic.Pointer[Scheduler]
+
+	// the watchers triggered in a Batch without a scheduler are queued in the batch of the goroutine
+	batches      = map[uint64]*batch{}
+	batchesMutex sync.Mutex
+)
+
+// a Batch running on a goroutine, which may be nested
+type batch struct {
+	scheduler *Scheduler
+	depth     int
+}
+
+// create a scheduler, which asks post to call flush some time later when a watcher is queued.
+// with a nil post, the queued watchers are only run by Flush.
+func NewScheduler(post func(flush func())) *Scheduler {
+	return &Scheduler{
+		post:   post,
+		queued: map[*Watcher]*queuedWatcher{},
+	}
+}
+
+// create a scheduler which flushes the watchers in the event loop of a tview application, which then draws the screen.
+// a flush is queued to the application only if the last one has run, even if Flush is called meanwhile.
+func NewAppScheduler(app *tview.Application) *Scheduler {
+	pending := atomic.Bool{}
+	return NewScheduler(func(flush func()) {
+		if !pending.CompareAndSwap(false, true) {
+			return
+		}
+		// QueueUpdateDraw blocks while the queue of the application is full, which may happen in the event loop itself
+		go app.QueueUpdateDraw(func() {
+			pending.Store(false)
+			flush()
+		})
+	})
+}
+
+// set the function to report the errors of the flushes, like a watcher which keeps triggering itself.
+// the errors are logged by the log package if there isn't one.
+func (s *Scheduler) SetErrorHandler(handler func(err error)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.errorHandler = handler
+}
+
+// report an error, with the mutex unlocked
+func (s *Scheduler) reportError(err error) {
+	s.mutex.Lock()
+	handler := s.errorHandler
+	s.mutex.Unlock()
+
+	if handler != nil {
+		handler(err)
+	} else {
+		log.Print(err)
+	}
+}
+
+// set the scheduler for all the Refs and watchers, or nil to run the watchers as soon as they are triggered
+func SetScheduler(s *Scheduler) {
+	scheduler.Store(s)
+}
+
+func currentScheduler() *Scheduler {
+	if s := scheduler.Load(); s != nil {
+		return s
+	}
+
+	batchesMutex.Lock()
+	defer batchesMutex.Unlock()
+	if len(batches) == 0 {
+		return nil
+	}
+	if b, ok := batches[goroutineID()]; ok {
+		return b.scheduler
+	}
+
+	return nil
+}
+
+// run a triggered watcher, or queue it if there is a scheduler
+func runWatcher(watcher *Watcher, ref Watchable, newVal interface{}, oldVal interface{}) {
+	if watcher.flushSync {
+		watcher.doSomething(ref, newVal, oldVal)
+		return
+	}
+
+	s := currentScheduler()
+	if s == nil {
+		watcher.doSomething(ref, newVal, oldVal)
+		return
+	}
+
+	s.add(watcher, ref, newVal, oldVal)
+}
+
+func (s *Scheduler) add(watcher *Watcher, ref Watchable, newVal interface{}, oldVal interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if q, ok := s.queued[watcher]; ok {
+		q.ref, q.newVal = ref, newVal
+		return
+	}
+
+	q := &queuedWatcher{
+		watcher: watcher,
+		ref:     ref,
+		newVal:  newVal,
+		oldVal:  oldVal,
+	}
+	s.queued[watcher] = q
+	s.queue = append(s.queue, q)
+	s.request()
+}
+
+// ask for a flush, with the mutex locked
+func (s *Scheduler) request() {
+	if s.posted || s.flushing || s.post == nil {
+		return
+	}
+
+	s.posted = true
+	s.post(s.Flush)
+}
+
+// run the queued watchers, and then the functions given to NextTick.
+// the watchers run in the order they were created, so that a watcher runs before the ones created while it runs,
+// like a page before the items of its v-for.
+func (s *Scheduler) Flush() {
+	s.mutex.Lock()
+	if s.flushing {
+		s.mutex.Unlock()
+		return
+	}
+	s.flushing = true
+	s.posted = false
+
+	// the watchers run with the mutex unlocked, as they may change some Refs, which queues more watchers
+	runs := map[*Watcher]int{}
+	for len(s.queue) > 0 {
+		sort.SliceStable(s.queue, func(i int, j int) bool {
+			return s.queue[i].watcher.id < s.queue[j].watcher.id
+		})
+		q := s.queue[0]
+		s.queue = s.queue[1:]
+		delete(s.queued, q.watcher)
+
+		runs[q.watcher] += 1
+		if runs[q.watcher] > maxWatcherRunsPerFlush {
+			if runs[q.watcher] == maxWatcherRunsPerFlush+1 {
+				s.mutex.Unlock()
+				s.reportError(tperr.NewTypedError("scheduler.recursiveUpdate", q.watcher.describe(), maxWatcherRunsPerFlush))
+				s.mutex.Lock()
+			}
+			continue
+		}
+		s.mutex.Unlock()
+		q.watcher.doSomething(q.ref, q.newVal, q.oldVal)
+		s.mutex.Lock()
+	}
+	s.flushing = false
+
+	ticks := s.ticks
+	s.ticks = nil
+	s.mutex.Unlock()
+
+	for _, tick := range ticks {
+		tick()
+	}
+
+	// the functions of NextTick may have changed some Refs
+	s.mutex.Lock()
+	requeued := len(s.queue) > 0
+	s.mutex.Unlock()
+	if requeued && s.post == nil {
+		s.Flush()
+	}
+}
+
+// run fn after the triggered watchers are run, like after a page is rendered again for the changed Refs.
+// fn runs at once if there is no scheduler and it is not in a Batch, as the watchers have run already.
+func NextTick(fn func()) {
+	s := currentScheduler()
+	if s == nil {
+		fn()
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ticks = append(s.ticks, fn)
+	s.request()
+}
+
+// run fn with the triggered watchers queued until it returns, and then run each of them once.
+// with a scheduler set, the watchers are left to the scheduler.
+// a batch only queues the watchers triggered on its own goroutine.
+func Batch(fn func()) {
+	if scheduler.Load() != nil {
+		fn()
+		return
+	}
+
+	gid := goroutineID()
+	batchesMutex.Lock()
+	b, ok := batches[gid]
+	if !ok {
+		b = &batch{scheduler: NewScheduler(nil)}
+		batches[gid] = b
+	}
+	b.depth += 1
+	batchesMutex.Unlock()
+
+	defer func() {
+		batchesMutex.Lock()
+		last := b.depth == 1
+		batchesMutex.Unlock()
+
+		// the batch is kept while it is flushed, so that the watchers triggered by the flush are queued in it as well
+		if last {
+			b.scheduler.Flush()
+		}
+
+		batchesMutex.Lock()
+		b.depth -= 1
+		if b.depth == 0 {
+			delete(batches, gid)
+		}
+		batchesMutex.Unlock()
+	}()
+
+	fn()
+}
+
+// the id of a watcher and the function it runs, like rview.(*Page).render.func1, to tell which one it is in an error
+func (w *Watcher) describe() string {
+	if w.watchWhat == nil {
+		return fmt.Sprintf("#%d", w.id)
+	}
+
+	fn := runtime.FuncForPC(reflect.ValueOf(w.watchWhat).Pointer())
+	if fn == nil {
+		return fmt.Sprintf("#%d", w.id)
+	}
+	return fmt.Sprintf("#%d (%s)", w.id, fn.Name())
+}
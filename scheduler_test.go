@@ -0,0 +1,218 @@
+package rview
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TinyWisp/rview/tperr"
+)
+
+func TestBatch(t *testing.T) {
+	refs := []*Ref[int]{NewRef(0), NewRef(0), NewRef(0), NewRef(0), NewRef(0)}
+
+	runs := 0
+	sum := 0
+	stop := RunReactively(func() {
+		sum = 0
+		for _, ref := range refs {
+			sum += ref.Get()
+		}
+		runs += 1
+	})
+	defer stop()
+
+	changes := []int{}
+	unwatch := WatchRef(refs[0], func(newVal int, oldVal int) {
+		changes = append(changes, oldVal, newVal)
+	}, false)
+	defer unwatch()
+
+	ticked := false
+	Batch(func() {
+		for i, ref := range refs {
+			ref.Set(i + 1)
+		}
+		refs[0].Set(10)
+		NextTick(func() {
+			ticked = true
+			if sum != 24 {
+				t.Fatalf("NextTick is expected to run after the watchers, got the sum: %d", sum)
+			}
+		})
+		if runs != 1 || ticked {
+			t.Fatal("the watchers are expected to wait for the end of the batch")
+		}
+	})
+
+	if runs != 2 || sum != 24 || !ticked {
+		t.Fatalf("the watcher is expected to run once for the batch, got: %d runs, sum %d", runs, sum)
+	}
+	if len(changes) != 2 || changes[0] != 0 || changes[1] != 10 {
+		t.Fatalf("the old value of the first change and the new value of the last one are expected, got: %v", changes)
+	}
+
+	// without a batch or a scheduler, a watcher runs at once
+	refs[1].Set(20)
+	if runs != 3 {
+		t.Fatalf("the watcher is expected to run at once, got: %d runs", runs)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	flushes := []func(){}
+	s := NewScheduler(func(flush func()) {
+		flushes = append(flushes, flush)
+	})
+	SetScheduler(s)
+	defer SetScheduler(nil)
+
+	a, b := NewRef("a"), NewRef("b")
+	order := []string{}
+	stopA := RunReactively(func() {
+		order = append(order, "A:"+a.Get())
+	})
+	defer stopA()
+	stopB := RunReactively(func() {
+		order = append(order, "B:"+b.Get()+a.Get())
+	})
+	defer stopB()
+
+	b.Set("b2")
+	a.Set("a2")
+	a.Set("a3")
+	NextTick(func() {
+		order = append(order, "tick")
+	})
+
+	// a flush is asked for once, and the watchers run in the order they were created
+	if len(flushes) != 1 {
+		t.Fatalf("a flush is expected to be asked for once, got: %d", len(flushes))
+	}
+	flushes[0]()
+
+	expect := "A:a,B:ba,A:a3,B:b2a3,tick"
+	if strings.Join(order, ",") != expect {
+		t.Fatalf("expect: %s, got: %s", expect, strings.Join(order, ","))
+	}
+
+	// a watcher changing a Ref it watches is stopped after some runs, rather than looping forever, which is reported
+	errs := []error{}
+	s.SetErrorHandler(func(err error) {
+		errs = append(errs, err)
+	})
+	c := NewRef(0)
+	stopC := RunReactively(func() {
+		c.Set(c.Get() + 1)
+	})
+	defer stopC()
+	c.Set(100)
+	flushes[len(flushes)-1]()
+	if c.value > 100+maxWatcherRunsPerFlush+1 {
+		t.Fatalf("the watcher is expected to be stopped, got: %d", c.value)
+	}
+	if len(errs) != 1 || !tperr.IsErrorType(errs[0], "scheduler.recursiveUpdate") || !strings.Contains(errs[0].Error(), "TestScheduler") {
+		t.Fatalf("the recursive update is expected to be reported once with the watcher, got: %v", errs)
+	}
+}
+
+func TestSchedulerPage(t *testing.T) {
+	flushes := []func(){}
+	SetScheduler(NewScheduler(func(flush func()) {
+		flushes = append(flushes, flush)
+	}))
+	defer SetScheduler(nil)
+
+	def := &ReactiveDef{
+		Tpl:     `<template><flex><textarea :text="Label(Row) + Suffix" /></flex></template>`,
+		Renders: map[string]int{},
+	}
+	type SchedulerDef struct {
+		*ReactiveDef
+		Row    *Ref[TodoItem]
+		Suffix *Ref[string]
+	}
+	sdef := &SchedulerDef{def, NewRef(TodoItem{Title: "a"}), NewRef("")}
+
+	page, err := NewPage(sdef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdef.Row.Set(TodoItem{Title: "a", Done: true})
+	sdef.Suffix.Set("!")
+	if len(flushes) != 1 || def.Renders["a"] != 1 {
+		t.Fatalf("the page is expected to wait for a flush")
+	}
+	flushes[0]()
+
+	text, _ := page.root.Children[0].Children[0].Comp.GetProp("text")
+	if def.Renders["a"] != 2 || text != "a (done)!" {
+		t.Fatalf("the page is expected to be rendered once for both changes, got: %d renders, %v", def.Renders["a"], text)
+	}
+}
+
+func TestConcurrentScheduler(t *testing.T) {
+	// a batch only queues the watchers triggered on its own goroutine
+	a, b := NewRef(0), NewRef(0)
+	runsA, runsB := 0, 0
+	stopA := RunReactively(func() { a.Get(); runsA += 1 })
+	defer stopA()
+	stopB := RunReactively(func() { b.Get(); runsB += 1 })
+	defer stopB()
+
+	Batch(func() {
+		a.Set(1)
+		done := make(chan bool)
+		go func() {
+			b.Set(1)
+			done <- true
+		}()
+		<-done
+		if runsA != 1 || runsB != 2 {
+			t.Fatalf("only the watchers triggered in the batch are expected to wait, got: %d, %d", runsA, runsB)
+		}
+	})
+	if runsA != 2 {
+		t.Fatalf("the watcher is expected to run at the end of the batch, got: %d", runsA)
+	}
+
+	// the reactive containers are changed on several goroutines, and the watchers run in the flush
+	flushes := make(chan func(), 100)
+	SetScheduler(NewScheduler(func(flush func()) {
+		flushes <- flush
+	}))
+	defer SetScheduler(nil)
+
+	rows := NewReactiveSlice(make([]int, 8))
+	tags := NewReactiveMap(map[int]int{})
+	sum := 0
+	stop := RunReactively(func() {
+		sum = 0
+		for _, val := range rows.Values() {
+			sum += val
+		}
+		sum += tags.Len()
+	})
+	defer stop()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < rows.Len(); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 1; j <= 100; j++ {
+				rows.Set(i, j)
+				tags.Set(i*100+j, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for len(flushes) > 0 {
+		(<-flushes)()
+	}
+	if sum != 8*100+800 {
+		t.Fatalf("the watcher is expected to see all the changes, got: %d", sum)
+	}
+}
@@ -0,0 +1,242 @@
+package rview
+
+import (
+	"reflect"
+
+	"github.com/TinyWisp/rview/ddl"
+	"github.com/TinyWisp/rview/tperr"
+)
+
+// a function to change a variable, used by the assignments of the statements
+type VarSetter func(name string, val *ddl.Exp) error
+
+// the statements of an event handler compiled into a closure, like "Count++" or "Selected = idx; Open = true"
+type CompiledStmt func(varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error)
+
+// an assignment compiled into a closure, which stores an evaluated value into its target
+type compiledAssignment func(val *ddl.Exp, varGetter VarGetter, varSetter VarSetter) error
+
+// execute the statements parsed by ddl.ParseStmt, and get the result of the last one
+func ExecStmt(stmt *ddl.Exp, varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error) {
+	return CompileStmt(stmt)(varGetter, varSetter)
+}
+
+func isStmt(exp *ddl.Exp) bool {
+	return exp != nil && exp.Type == ddl.ExpCalc &&
+		(exp.Operator == ";" || exp.Operator == "=" || exp.Operator == "++" || exp.Operator == "--")
+}
+
+func CompileStmt(stmt *ddl.Exp) CompiledStmt {
+	// an expression, like Save(idx)
+	if !isStmt(stmt) {
+		run := CompileExp(stmt)
+		return func(varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error) {
+			return run(varGetter)
+		}
+	}
+
+	run := CompiledStmt(nil)
+	switch stmt.Operator {
+	case ";":
+		left, right := CompileStmt(stmt.Left), CompileStmt(stmt.Right)
+		run = func(varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error) {
+			if _, err := left(varGetter, varSetter); err != nil {
+				return nil, err
+			}
+			return right(varGetter, varSetter)
+		}
+
+	case "=":
+		val, assign := CompileExp(stmt.Right), compileAssignment(stmt.Left)
+		run = func(varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error) {
+			res, err := val(varGetter)
+			if err != nil {
+				return nil, err
+			}
+			return res, assign(res, varGetter, varSetter)
+		}
+
+	// a++ and a--, which only apply to numbers
+	case "++", "--":
+		target, assign := CompileExp(stmt.Left), compileAssignment(stmt.Left)
+		operate := calcPlus
+		if stmt.Operator == "--" {
+			operate = calcMinus
+		}
+		one := &ddl.Exp{
+			Type: ddl.ExpInt,
+			Int:  1,
+		}
+		run = func(varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error) {
+			old, err := target(varGetter)
+			if err != nil {
+				return nil, err
+			}
+			if old.Type != ddl.ExpInt && old.Type != ddl.ExpFloat {
+				return nil, tperr.NewTypedError("calc.operandTypeMismatch", stmt.Operator, old.ActualTypeName(), ddl.ExpTypeName[ddl.ExpInt])
+			}
+
+			res, err := operate(old, one)
+			if err != nil {
+				return nil, err
+			}
+			return res, assign(res, varGetter, varSetter)
+		}
+	}
+
+	return func(varGetter VarGetter, varSetter VarSetter) (*ddl.Exp, error) {
+		res, err := run(varGetter, varSetter)
+		if err != nil {
+			if _, ok := err.(*ddl.DdlError); !ok {
+				err = ddl.WrapDdlError("", stmt.Pos, err)
+			}
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// a variable is changed by the setter, while a field, a map entry or an element is changed in its container.
+// a container held by value, like a struct or an array, is changed on a copy, which is then assigned back to where it came from,
+// so Items[0].Name = "x" changes the element of Items, and Chicken.Name = "x" gives the changed copy of Chicken to the setter.
+func compileAssignment(target *ddl.Exp) compiledAssignment {
+	if target.Type == ddl.ExpVar {
+		return func(val *ddl.Exp, varGetter VarGetter, varSetter VarSetter) error {
+			return varSetter(target.Variable, val)
+		}
+	}
+
+	container, key := CompileExp(target.Left), CompileExp(target.Right)
+	writeBack := compiledAssignment(nil)
+	if target.Left.Type == ddl.ExpVar || (target.Left.Type == ddl.ExpCalc && (target.Left.Operator == "." || target.Left.Operator == "[")) {
+		writeBack = compileAssignment(target.Left)
+	}
+
+	return func(val *ddl.Exp, varGetter VarGetter, varSetter VarSetter) error {
+		cexp, err := container(varGetter)
+		if err != nil {
+			return err
+		}
+		kexp, err := key(varGetter)
+		if err != nil {
+			return err
+		}
+		if cexp.Type != ddl.ExpInterface {
+			return tperr.NewTypedError("calc.cannotAssign", cexp.ActualTypeName())
+		}
+
+		updated, copied, err := setElement(reflect.ValueOf(cexp.Interface), kexp, val)
+		if err != nil || !copied {
+			return err
+		}
+		if writeBack == nil {
+			return tperr.NewTypedError("calc.cannotAssign", cexp.ActualTypeName())
+		}
+
+		return writeBack(&ddl.Exp{
+			Type:      ddl.ExpInterface,
+			Interface: updated.Interface(),
+		}, varGetter, varSetter)
+	}
+}
+
+// set a field of a struct, an entry of a map or an element of a slice or an array.
+// a struct or an array is changed on a copy, which is returned with true as the second result.
+func setElement(container reflect.Value, key *ddl.Exp, val *ddl.Exp) (reflect.Value, bool, error) {
+	// a part of a ReactiveSlice, a ReactiveMap or a ReactiveStruct triggers only the watchers reading it
+	if container.IsValid() && container.CanInterface() {
+		if reactive, ok := container.Interface().(reactiveContainer); ok {
+			return reflect.Value{}, false, reactive.assignPart(key, val)
+		}
+	}
+
+	switch container.Kind() {
+	case reflect.Pointer:
+		if container.IsNil() || container.Elem().Kind() != reflect.Struct {
+			break
+		}
+		if key.Type != ddl.ExpStr {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.operandTypeMismatch", ".", container.Type().String(), key.ActualTypeName())
+		}
+		return reflect.Value{}, false, AssignStructField(container.Interface(), key.Str, val)
+
+	case reflect.Struct:
+		if key.Type != ddl.ExpStr {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.operandTypeMismatch", ".", container.Type().String(), key.ActualTypeName())
+		}
+		ptr := reflect.New(container.Type())
+		ptr.Elem().Set(container)
+		return ptr.Elem(), true, AssignStructField(ptr.Interface(), key.Str, val)
+
+	case reflect.Map:
+		if container.IsNil() {
+			break
+		}
+		mapKey, ok := expToTypedValue(key, container.Type().Key())
+		if !ok {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.assignmentTypeMismatch", key.ActualTypeName(), container.Type().Key().String())
+		}
+		mapVal, ok := expToTypedValue(val, container.Type().Elem())
+		if !ok {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.assignmentTypeMismatch", val.ActualTypeName(), container.Type().Elem().String())
+		}
+		container.SetMapIndex(mapKey, mapVal)
+		return reflect.Value{}, false, nil
+
+	case reflect.Slice, reflect.Array:
+		if key.Type != ddl.ExpInt {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.operandTypeMismatch", "[", container.Type().String(), key.ActualTypeName())
+		}
+		if key.Int < 0 || key.Int >= int64(container.Len()) {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.indexOutOfRange", key.Int, container.Len())
+		}
+		elem, ok := expToTypedValue(val, container.Type().Elem())
+		if !ok {
+			return reflect.Value{}, false, tperr.NewTypedError("calc.assignmentTypeMismatch", val.ActualTypeName(), container.Type().Elem().String())
+		}
+
+		// the elements of a slice are shared with the variable, while an array is a value like a struct
+		if container.Kind() == reflect.Slice {
+			container.Index(int(key.Int)).Set(elem)
+			return reflect.Value{}, false, nil
+		}
+		arr := reflect.New(container.Type()).Elem()
+		arr.Set(container)
+		arr.Index(int(key.Int)).Set(elem)
+		return arr, true, nil
+	}
+
+	return reflect.Value{}, false, tperr.NewTypedError("calc.cannotAssign", container.Type().String())
+}
+
+// set a field of a struct, or of a pointer to a struct, to an evaluated value.
+// the value is converted to the type of the field, and a Ref field is set through its Set method, so that its watchers are triggered.
+func AssignStructField(structVar interface{}, field string, val *ddl.Exp) error {
+	structVal := reflect.Indirect(reflect.ValueOf(structVar))
+	index := structFieldIndex(structVal.Type(), field)
+	if index == nil {
+		return tperr.NewTypedError("util.SetStructField.fieldNotExist", field)
+	}
+	// a field promoted from a nil embedded pointer
+	fieldVal, err := structVal.FieldByIndexErr(index)
+	if err != nil {
+		return tperr.NewTypedError("util.SetStructField.cannotSetFieldValue", field)
+	}
+	if !fieldVal.CanInterface() {
+		return tperr.NewTypedError("util.SetStructField.unexportedField", field)
+	}
+
+	fieldType := fieldVal.Type()
+	if isRef(fieldVal.Interface()) {
+		if typable, ok := fieldVal.Interface().(interface{ Type() reflect.Type }); ok {
+			fieldType = typable.Type()
+		}
+	}
+
+	goVal, ok := expToTypedValue(val, fieldType)
+	if !ok {
+		return tperr.NewTypedError("calc.assignmentTypeMismatch", val.ActualTypeName(), fieldType.String())
+	}
+
+	return SetStructField(structVar, field, goVal.Interface())
+}
@@ -0,0 +1,115 @@
+package rview
+
+import (
+	"testing"
+
+	"github.com/TinyWisp/rview/ddl"
+	"github.com/TinyWisp/rview/tperr"
+)
+
+type TodoItem struct {
+	Title string
+	Done  bool
+}
+
+type TodoStruct struct {
+	Title   string
+	Count   *Ref[int]
+	Items   []TodoItem
+	Tags    map[string]string
+	Ratio   float64
+	Pair    [2]int
+	private int
+}
+
+func TestExecStmt(t *testing.T) {
+	type stmtCase struct {
+		stmt   string
+		check  string
+		expect string
+		err    string
+	}
+
+	cases := []stmtCase{
+		{stmt: `count = count + 1`, check: `count`, expect: `1`},
+		{stmt: `count++; count++`, check: `count`, expect: `3`},
+		{stmt: `count--`, check: `count`, expect: `2`},
+		{stmt: `name = "todo"; count = len`, check: `name + count`, expect: `"todo5"`},
+		{stmt: `todo.Title = name`, check: `todo.Title`, expect: `"todo"`},
+		{stmt: `todo.Count = 10; todo.Count++`, check: `todo.Count`, expect: `11`},
+		{stmt: `todo.Items[1].Done = !todo.Items[1].Done`, check: `todo.Items[1].Done`, expect: `true`},
+		{stmt: `todo.Tags["color"] = "red"`, check: `todo.Tags.color`, expect: `"red"`},
+		{stmt: `todo.Ratio = 1`, check: `todo.Ratio`, expect: `1.0`},
+		{stmt: `todo.Pair[1] = 7`, check: `todo.Pair[1]`, expect: `7`},
+		{stmt: `item.Title = "copy"`, check: `item.Title`, expect: `"copy"`},
+		{stmt: `todoPtr.Title = "pointer"`, check: `todoPtr.Title`, expect: `"pointer"`},
+		{stmt: `todo.Items[5].Done = true`, err: `calc.indexOutOfRange`},
+		{stmt: `todo.Title = 1`, err: `calc.assignmentTypeMismatch`},
+		{stmt: `todo.Missing = 1`, err: `util.SetStructField.fieldNotExist`},
+		{stmt: `todo.private = 1`, err: `util.SetStructField.unexportedField`},
+		{stmt: `name++`, err: `calc.operandTypeMismatch`},
+		{stmt: `len.a = 1`, err: `calc.cannotAssign`},
+		{stmt: `getTodo().Title = "x"`, err: `calc.cannotAssign`},
+	}
+
+	todo := TodoStruct{
+		Count: NewRef(0),
+		Items: []TodoItem{{Title: "a"}, {Title: "b"}},
+		Tags:  map[string]string{},
+	}
+	todoPtr := &TodoStruct{}
+	vars := map[string]interface{}{
+		"count":   0,
+		"name":    "",
+		"len":     5,
+		"todo":    todo,
+		"todoPtr": todoPtr,
+		"item":    TodoItem{Title: "a"},
+		"getTodo": func() TodoStruct { return todo },
+	}
+	getVar := func(name string) (interface{}, error) {
+		return vars[name], nil
+	}
+	setVar := func(name string, val *ddl.Exp) error {
+		vars[name] = expToValue(val)
+		return nil
+	}
+
+	changes := 0
+	stop := WatchRef(todo.Count, func(newVal int, oldVal int) { changes += 1 }, false)
+	defer stop()
+
+	for _, testCase := range cases {
+		t.Log(testCase.stmt)
+
+		stmt, err := ddl.ParseStmt(testCase.stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ExecStmt(stmt, getVar, setVar)
+		if testCase.err != "" {
+			if !tperr.IsErrorType(err, testCase.err) {
+				t.Fatalf("expect the error %s, got: %v", testCase.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check, _ := ddl.ParseExp(testCase.check)
+		res, err := CalcExp(check, getVar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, _ := ddl.ParseExp(testCase.expect)
+		if !expect.Equal(res) {
+			t.Fatalf("expect: %s, got: %s", testCase.expect, res.ToString())
+		}
+	}
+
+	// the Ref field is set through its Set method
+	if changes != 2 {
+		t.Fatalf("the watcher of the Ref field is expected to be triggered twice, got: %d", changes)
+	}
+}
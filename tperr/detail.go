@@ -0,0 +1,147 @@
+package tperr
+
+import (
+	"fmt"
+)
+
+// the vars of some error types, as typed fields, get them with errors.As:
+//
+//	var operr *tperr.OperandTypeError
+//	if errors.As(err, &operr) {
+//		fmt.Println(operr.Operator, operr.Left, operr.Right)
+//	}
+
+// calc.operandTypeMismatch
+type OperandTypeError struct {
+	*TypedError
+
+	Operator string
+	Left     string
+	Right    string
+}
+
+// calc.argumentNumberMismatch, calc.argumentNumberNotEnough
+type ArgumentCountError struct {
+	*TypedError
+
+	Func     string
+	Expected int
+	Got      int
+	Variadic bool
+}
+
+// page.undefinedVariable, calc.variableIsNotFunc, calc.varIsNotFuncType
+type VariableError struct {
+	*TypedError
+
+	Name string
+}
+
+// util.SetStructField.*, util.GetStructField.*, except the type mismatch
+type FieldError struct {
+	*TypedError
+
+	Field string
+}
+
+// comp.SetProp.propNotAllowed, comp.SetProp.propTypeMismatch, comp.GetProp.propNotExist
+type PropError struct {
+	*TypedError
+
+	Prop     string
+	Comp     string
+	Got      string
+	Expected string
+}
+
+func varString(vars []any, idx int) string {
+	if idx >= len(vars) {
+		return ""
+	}
+	return fmt.Sprint(vars[idx])
+}
+
+func varInt(vars []any, idx int) int {
+	if idx >= len(vars) {
+		return 0
+	}
+	num, _ := vars[idx].(int)
+	return num
+}
+
+// fill the detail struct pointed by target with the vars of an error type.
+// it is used by the As method of the typed errors.
+func AsDetail(etype string, vars []any, target any) bool {
+	switch t := target.(type) {
+	case **OperandTypeError:
+		if etype != "calc.operandTypeMismatch" {
+			return false
+		}
+		*t = &OperandTypeError{
+			TypedError: NewTypedError(etype, vars...),
+			Operator:   varString(vars, 0),
+			Left:       varString(vars, 1),
+			Right:      varString(vars, 2),
+		}
+		return true
+
+	case **ArgumentCountError:
+		if etype != "calc.argumentNumberMismatch" && etype != "calc.argumentNumberNotEnough" {
+			return false
+		}
+		*t = &ArgumentCountError{
+			TypedError: NewTypedError(etype, vars...),
+			Func:       varString(vars, 0),
+			Expected:   varInt(vars, 1),
+			Got:        varInt(vars, 2),
+			Variadic:   etype == "calc.argumentNumberNotEnough",
+		}
+		return true
+
+	case **VariableError:
+		switch etype {
+		case "page.undefinedVariable", "calc.variableIsNotFunc", "calc.varIsNotFuncType":
+			*t = &VariableError{
+				TypedError: NewTypedError(etype, vars...),
+				Name:       varString(vars, 0),
+			}
+			return true
+		}
+		return false
+
+	case **FieldError:
+		switch etype {
+		case "util.SetStructField.fieldNotExist", "util.SetStructField.unexportedField", "util.SetStructField.cannotSetFieldValue",
+			"util.GetStructField.fieldNotExist", "util.GetStructField.unexportedField", "util.GetStructField.unavailableField":
+			*t = &FieldError{
+				TypedError: NewTypedError(etype, vars...),
+				Field:      varString(vars, 0),
+			}
+			return true
+		}
+		return false
+
+	case **PropError:
+		switch etype {
+		case "comp.SetProp.propNotAllowed", "comp.GetProp.propNotExist":
+			*t = &PropError{
+				TypedError: NewTypedError(etype, vars...),
+				Prop:       varString(vars, 0),
+				Comp:       varString(vars, 1),
+			}
+			return true
+		case "comp.SetProp.propTypeMismatch":
+			*t = &PropError{
+				TypedError: NewTypedError(etype, vars...),
+				Got:        varString(vars, 0),
+				Prop:       varString(vars, 1),
+				Comp:       varString(vars, 2),
+				Expected:   varString(vars, 3),
+			}
+			return true
+		}
+		return false
+	}
+
+	return false
+}
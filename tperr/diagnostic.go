@@ -0,0 +1,252 @@
+package tperr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+var SeverityName = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+}
+
+func (s Severity) String() string {
+	return SeverityName[s]
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// a position in a source. Line and Col are 1-based, and Col counts characters, not bytes.
+type Location struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+}
+
+type Span struct {
+	Start Location `json:"start"`
+	End   Location `json:"end"`
+}
+
+type Note struct {
+	Message string `json:"message"`
+	Span    *Span  `json:"span,omitempty"`
+}
+
+type Diagnostic struct {
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	Span     *Span    `json:"span,omitempty"`
+	Notes    []Note   `json:"notes,omitempty"`
+	source   string
+}
+
+// implemented by the errors that can be reported as a diagnostic
+type Diagnosable interface {
+	Diagnostic() *Diagnostic
+}
+
+// create a diagnostic for the byte range [begin, end) of the source.
+// if begin is negative, the diagnostic is not attached to any position.
+func NewDiagnostic(source string, begin int, end int, severity Severity, code string, vars ...any) *Diagnostic {
+	diag := &Diagnostic{
+		Code:     code,
+		Severity: severity,
+		Message:  fmt.Sprintf(T(code), vars...),
+		source:   source,
+	}
+
+	if begin >= 0 && source != "" {
+		diag.Span = NewSpan(source, begin, end)
+	}
+
+	return diag
+}
+
+func NewSpan(source string, begin int, end int) *Span {
+	if begin > len(source) {
+		begin = len(source)
+	}
+	if end < begin {
+		end = begin
+	}
+	if end > len(source) {
+		end = len(source)
+	}
+
+	return &Span{
+		Start: NewLocation(source, begin),
+		End:   NewLocation(source, end),
+	}
+}
+
+func NewLocation(source string, offset int) Location {
+	if offset > len(source) {
+		offset = len(source)
+	}
+
+	before := source[:offset]
+	lineBegin := strings.LastIndexByte(before, '\n') + 1
+
+	return Location{
+		Offset: offset,
+		Line:   strings.Count(before, "\n") + 1,
+		Col:    utf8.RuneCountInString(before[lineBegin:]) + 1,
+	}
+}
+
+func (d *Diagnostic) SetFile(file string) *Diagnostic {
+	d.File = file
+	return d
+}
+
+// attach a note, pointing at the byte range [begin, end) of the source if begin is not negative
+func (d *Diagnostic) AddNote(begin int, end int, code string, vars ...any) *Diagnostic {
+	note := Note{
+		Message: fmt.Sprintf(T(code), vars...),
+	}
+	if begin >= 0 && d.source != "" {
+		note.Span = NewSpan(d.source, begin, end)
+	}
+	d.Notes = append(d.Notes, note)
+
+	return d
+}
+
+func (d *Diagnostic) JSON() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+func RenderDiagnosticsJSON(diags []*Diagnostic) ([]byte, error) {
+	if diags == nil {
+		diags = []*Diagnostic{}
+	}
+
+	return json.MarshalIndent(diags, "", "  ")
+}
+
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiCyan   = "\033[36m"
+)
+
+// render the diagnostic for a terminal, like:
+//
+//	error[tpl.mismatchedTag]: mismatched tag
+//	  --> page.rview:3:1
+//	   |
+//	 3 | </template>
+//	   | ^^^^^^^^^^^
+func (d *Diagnostic) Render(colorful bool) string {
+	paint := func(color string, str string) string {
+		if !colorful {
+			return str
+		}
+		return color + str + ansiReset
+	}
+
+	severityColor := ansiRed
+	if d.Severity == SeverityWarning {
+		severityColor = ansiYellow
+	} else if d.Severity == SeverityInfo {
+		severityColor = ansiCyan
+	}
+
+	str := paint(ansiBold+severityColor, fmt.Sprintf("%s[%s]", d.Severity, d.Code)) + paint(ansiBold, ": "+d.Message) + "\n"
+	if d.Span == nil {
+		return str
+	}
+
+	lines := strings.Split(d.source, "\n")
+	gutter := len(fmt.Sprintf("%d", d.Span.End.Line))
+	pad := strings.Repeat(" ", gutter)
+
+	file := d.File
+	if file == "" {
+		file = "<template>"
+	}
+	str += fmt.Sprintf("%s%s %s:%d:%d\n", pad, paint(ansiBlue, "-->"), file, d.Span.Start.Line, d.Span.Start.Col)
+	str += fmt.Sprintf("%s %s\n", pad, paint(ansiBlue, "|"))
+
+	for lineNo := d.Span.Start.Line; lineNo <= d.Span.End.Line && lineNo <= len(lines); lineNo++ {
+		// a span ending at the beginning of a line doesn't cover that line
+		if lineNo > d.Span.Start.Line && lineNo == d.Span.End.Line && d.Span.End.Col == 1 {
+			break
+		}
+
+		line := lines[lineNo-1]
+		str += fmt.Sprintf("%s %s %s\n", paint(ansiBlue, fmt.Sprintf("%*d", gutter, lineNo)), paint(ansiBlue, "|"), line)
+
+		// the columns to underline on this line
+		beginCol := 1
+		if lineNo == d.Span.Start.Line {
+			beginCol = d.Span.Start.Col
+		}
+		endCol := utf8.RuneCountInString(line) + 1
+		if lineNo == d.Span.End.Line {
+			endCol = d.Span.End.Col
+		}
+		if endCol <= beginCol {
+			endCol = beginCol + 1
+		}
+
+		indent := ""
+		marker := ""
+		col := 1
+		for _, ch := range line + " " {
+			width := runewidth.RuneWidth(ch)
+			if ch == '\t' {
+				width = 1
+			}
+			if col < beginCol {
+				if ch == '\t' {
+					indent += "\t"
+				} else {
+					indent += strings.Repeat(" ", width)
+				}
+			} else if col < endCol {
+				marker += strings.Repeat("^", maxInt(width, 1))
+			}
+			col += 1
+		}
+		str += fmt.Sprintf("%s %s %s%s\n", pad, paint(ansiBlue, "|"), indent, paint(ansiBold+severityColor, marker))
+	}
+
+	for _, note := range d.Notes {
+		str += fmt.Sprintf("%s %s %s", pad, paint(ansiBlue, "="), paint(ansiBold, "note: ")+note.Message)
+		if note.Span != nil {
+			str += fmt.Sprintf(" (%s:%d:%d)", file, note.Span.Start.Line, note.Span.Start.Col)
+		}
+		str += "\n"
+	}
+
+	return str
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
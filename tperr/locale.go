@@ -0,0 +1,152 @@
+package tperr
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	catalogMutex sync.RWMutex
+	catalogs     = map[string]map[string]string{}
+	locale       = DefaultLocale
+)
+
+func init() {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		bytes, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(bytes, &catalog); err != nil {
+			panic("tperr: invalid catalog " + entry.Name() + ": " + err.Error())
+		}
+		RegisterCatalog(strings.TrimSuffix(entry.Name(), ".json"), catalog)
+	}
+}
+
+// make "zh_cn", "ZH-cn" and so on be "zh-CN"
+func normalizeLocale(loc string) string {
+	parts := strings.Split(strings.ReplaceAll(loc, "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+
+	return strings.Join(parts, "-")
+}
+
+// register a message catalog, or add messages to an existing one
+func RegisterCatalog(loc string, msgs map[string]string) {
+	loc = normalizeLocale(loc)
+
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+
+	catalog, ok := catalogs[loc]
+	if !ok {
+		catalog = map[string]string{}
+		catalogs[loc] = catalog
+	}
+	for key, msg := range msgs {
+		catalog[key] = msg
+	}
+}
+
+// switch the language of the messages.
+// "zh_CN.UTF-8" and "zh" both select the "zh-CN" catalog if there is no better match.
+func SetLocale(loc string) error {
+	if idx := strings.IndexAny(loc, ".@"); idx >= 0 {
+		loc = loc[:idx]
+	}
+	loc = normalizeLocale(loc)
+
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+
+	if _, ok := catalogs[loc]; ok || loc == DefaultLocale {
+		locale = loc
+		return nil
+	}
+
+	// the same language of another region
+	lang := strings.SplitN(loc, "-", 2)[0]
+	if lang == DefaultLocale {
+		locale = DefaultLocale
+		return nil
+	}
+	candidates := []string{}
+	for name := range catalogs {
+		if name == lang || strings.HasPrefix(name, lang+"-") {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) > 0 {
+		sort.Strings(candidates)
+		locale = candidates[0]
+		return nil
+	}
+
+	return NewTypedError("tperr.unsupportedLocale", loc)
+}
+
+func GetLocale() string {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+
+	return locale
+}
+
+// all available locales, including the default one
+func Locales() []string {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+
+	locales := []string{DefaultLocale}
+	for name := range catalogs {
+		if name != DefaultLocale {
+			locales = append(locales, name)
+		}
+	}
+	sort.Strings(locales[1:])
+
+	return locales
+}
+
+// the keys of the english messages which are not translated in the catalog
+func MissingKeys(loc string) []string {
+	loc = normalizeLocale(loc)
+
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+
+	missing := []string{}
+	if loc == DefaultLocale {
+		return missing
+	}
+	catalog := catalogs[loc]
+	for key := range msgMap {
+		if _, ok := catalog[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
@@ -0,0 +1,62 @@
+package tperr
+
+import (
+	"testing"
+)
+
+func TestSetLocale(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	testCases := []struct {
+		locale string
+		expect string
+		err    string
+	}{
+		{locale: "zh-CN", expect: "zh-CN"},
+		{locale: "zh_cn.UTF-8", expect: "zh-CN"},
+		{locale: "zh", expect: "zh-CN"},
+		{locale: "en_US", expect: "en"},
+		{locale: "xx", expect: "en", err: "tperr.unsupportedLocale"},
+	}
+	for _, testCase := range testCases {
+		SetLocale(DefaultLocale)
+		err := SetLocale(testCase.locale)
+		if testCase.err == "" && err != nil {
+			t.Fatal(err)
+		}
+		if testCase.err != "" && (err == nil || err.(*TypedError).GetEtype() != testCase.err) {
+			t.Fatalf("%s: expect the error %s, got %v", testCase.locale, testCase.err, err)
+		}
+		if GetLocale() != testCase.expect {
+			t.Fatalf("%s: expect the locale %s, got %s", testCase.locale, testCase.expect, GetLocale())
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	RegisterCatalog("zz", map[string]string{
+		"page.undefinedVariable": "zz: %s",
+	})
+	if err := SetLocale("zz"); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := NewTypedError("page.undefinedVariable", "a").Error(); msg != "zz: a" {
+		t.Fatalf("the translated message is expected, got: %s", msg)
+	}
+	if msg := T("page.compNotFound"); msg != msgMap["page.compNotFound"] {
+		t.Fatalf("the english message is expected for a missing key, got: %s", msg)
+	}
+	if msg := T("no.such.key"); msg != "no.such.key" {
+		t.Fatalf("the key itself is expected for an unknown key, got: %s", msg)
+	}
+
+	if missing := MissingKeys("zz"); len(missing) != len(msgMap)-1 {
+		t.Fatalf("%d missing keys are expected, got %d", len(msgMap)-1, len(missing))
+	}
+	if missing := MissingKeys("zh-CN"); len(missing) != 0 {
+		t.Fatalf("the zh-CN catalog is incomplete: %v", missing)
+	}
+}
@@ -1,5 +1,7 @@
 package tperr
 
+// the english messages, which are the fallback of all other catalogs
+
 var msgMap = map[string]string{
 	"css.mismatchedCurlyBrace":          "mismatched brace",
 	"css.unexpectedToken":               "unexpected token",
@@ -9,15 +11,24 @@ var msgMap = map[string]string{
 	"css.mismatchedSingleQuotationMark": "mismatched single quotation mark",
 	"css.mismatchedDoubleQuotationMark": "mismatched double quotation mark",
 	"css.mismatchedParenthesis":         "mismatched parenthesis",
+	"css.unterminatedComment":           "unterminated comment",
 
 	"exp.mismatchedCurlyBrace":          "mismatched brace",
 	"exp.mismatchedSingleQuotationMark": "mismatched single quotation mark",
 	"exp.mismatchedDoubleQuotationMark": "mismatched double quotation mark",
 	"exp.mismatchedParenthesis":         "mismatched parenthesis",
+	"exp.mismatchedSquareBracket":       "mismatched square bracket",
 	"exp.unexpectedToken":               "unexpected token",
 	"exp.incompleteExpression":          "incomplete expression",
 	"exp.invalidTenaryExpression":       "invalid tenary expression",
 	"exp.expectingParameter":            "expecting a parameter",
+	"exp.integerOverflow":               "integer out of range: %s",
+	"exp.expectingElement":              "expecting an element",
+	"exp.invalidMapKey":                 "invalid map key; expected a name or a string",
+	"exp.invalidMapEntry":               "invalid map entry; expected 'key: value'",
+	"exp.invalidFilter":                 "invalid filter; expected a filter name like 'upper' or a call like 'currency(\"USD\")' after '|'",
+	"exp.statementNotAllowed":           "'%s' is only allowed in the statements of an event handler",
+	"exp.invalidAssignmentTarget":       "invalid assignment target; expected a variable, a field or an element like 'a', 'a.b' or 'a[0]'",
 
 	"tpl.missingOpeningTag":             "missing opening tag",
 	"tpl.missingClosingTag":             "missing closing tag",
@@ -30,7 +41,16 @@ var msgMap = map[string]string{
 	"tpl.conflictedDirective":           "conflicted directives",
 	"tpl.duplicateEventHandler":         "duplicate event handler",
 	"tpl.invalidVforDirective":          "invalid v-for directive",
+	"tpl.invalidDirective":              "invalid directive: %s",
 	"tpl.invalidDefAttr":                "invalid def attribute",
+	"tpl.unexpectedToken":               "unexpected token",
+	"tpl.mismatchedCurlyBrace":          "mismatched curly brace",
+	"tpl.unterminatedComment":           "unterminated comment",
+	"tpl.note.previousDefinition":       "previously defined here",
+	"tpl.note.openingTag":               "<%s> is opened here",
+
+	"ddl.invalidStyleSection": "the style section must contain only css",
+	"ddl.wrapped":             "%s",
 
 	"util.SetStructField.fieldNotExist":       "invalid field: %s",
 	"util.SetStructField.typeMismatch":        "cannot assign %s to %s",
@@ -41,38 +61,77 @@ var msgMap = map[string]string{
 	"util.GetStructField.unavailableField":    "unavailable field: %s",
 
 	"calc.emptyVariableName":       "empty variable name",
+	"calc.emptyFuncName":           "empty function name",
+	"calc.unsupportedOperator":     "unsupported operator: %s",
 	"calc.operandTypeMismatch":     "Type mismatch - cannot perform '%s' operation between '%s' and '%s'",
 	"calc.invalidTernaryCondition": "The ternary condition must evaluate to a boolean. Received type '%s'",
-	"calc.ternaryDataNotSameType":  `In a ternary expression "exp ? a : b", a and b must have the same data type.`,
 	"calc.variableIsNotFunc":       `'%s' is not a function`,
 	"calc.expMustBeVarType":        `the '%s' must be a *ddl.Exp with the ddl.ExpVar type`,
 	"calc.expMustBeFuncType":       `the '%s' must be a *ddl.Exp with the ddl.ExpFunc type`,
 	"calc.varIsNotFuncType":        `the '%s' is not a function`,
 	"calc.argumentNumberMismatch":  `function "%s" expect %d arguments, but got %d`,
 	"calc.argumentNumberNotEnough": `function "%s" expect %d or more arguments, but got %d`,
+	"calc.integerOverflow":         "integer overflow: %d %s %d",
+	"calc.divisionByZero":          "division by zero",
+	"calc.argumentTypeMismatch":    `function "%s": cannot use a %[4]s as argument %[2]d; expected a %[3]s`,
+	"calc.funcReturnedError":       `function "%s" returned an error: %s`,
+	"calc.methodNotExist":          `'%s' has no method '%s'`,
+	"calc.filterNotExist":          "unknown filter: %s",
+	"calc.invalidFilter":           `invalid filter "%s": a filter must be a function with at least one parameter and one result`,
+	"calc.invalidHelper":           `invalid helper "%s": a helper must be a function with at least one result`,
+	"calc.unsupportedArgument":     "%s does not support a %s",
+	"calc.cannotAssign":            "cannot assign to an element of a %s",
+	"calc.assignmentTypeMismatch":  "cannot assign a %s to a %s",
+	"calc.indexOutOfRange":         "index %d out of range with length %d",
 
 	"comp.SetProp.propNotAllowed":               "invalid property: '%s' is not allowed on '%s",
 	"comp.SetProp.propTypeMismatch":             "invalid property: cannot assign a %s to '%s' on <%s>; expected a %s",
 	"comp.SetProp.propSetterMustBeOneParameter": "",
+	"comp.GetProp.propNotExist":                 "invalid property: '%s' does not exist on '%s'",
+	"comp.SetEventHandler.eventNotSupported":    "unsupported event: '%s' is not supported by '%s'",
 
 	"comp.colorPropNotValid":  `invalid value %s; expected a known color name like "green", "black", or a hex code like "#FF0000"`,
 	"comp.titleAlignNotValid": `invalid value for "titleAlign": got "%s", expected one of "left", "right", or "center"`,
 
 	"page.tplFieldIsRequired":               "the Tpl field is required.",
+	"page.tplMustBeString":                  "the Tpl field must be a string.",
+	"page.mainTemplateBeEssential":          "the main template is essential.",
+	"page.invalidTypeOfComponentsField":     "the Components field must be a map[string]func() comp.Component.",
+	"page.invalidTypeOfFiltersField":        "the Filters field must be a map[string]interface{}.",
+	"page.invalidTypeOfDirectivesField":     "the Directives field must be a map[string]*rview.Directive.",
+	"page.invalidDirective":                 `invalid directive "%s": the name is taken by a built-in directive, or the directive is nil`,
+	"page.invalidTypeOfMapOrderField":       "the MapOrder field must be a func(a interface{}, b interface{}) bool.",
 	"page.tplMustContainOneRootNode":        "the template must contain one root node.",
 	"page.tplMustContainExactlyOneRootNode": "the template must contain exactly one root node.",
 	"page.undefinedVariable":                "undefined variable: %s",
 	"page.compNotFound":                     "component not found: '%s' is not recognized. check if it is registered or spelled correctly.",
+	"page.directiveNotFound":                "directive not found: 'v-%s' is not recognized. check if it is registered or spelled correctly.",
 	"page.cannotResolveComponent":           "failed to resolve component: %s",
-	"page.vifDirectiveMustBeBool":           "invalid expression in v-if: expected a boolean, got %s instead",
-	"page.velseifDirectiveMustBeBool":       "invalid expression in v-else-if: expected a boolean, got %s instead",
+	"page.vifDirectriveMustBeBool":          "invalid expression in v-if: expected a boolean, got %s instead",
+	"page.velseifDirectriveMustBeBool":      "invalid expression in v-else-if: expected a boolean, got %s instead",
 	"page.velseDirectiveMustBeBool":         "invalid expression in v-else: expected a boolean, got %s instead",
+	"page.vshowDirectiveMustBeBool":         "invalid expression in v-show: expected a boolean, got %s instead",
+	"page.vmemoDirectiveMustBeArray":        "invalid expression in v-memo: expected an array, got %s instead",
 	"page.velseHasNoCorrespondingIf":        "v-else directive requires a preceding v-if sibling. No matching v-if found.",
 	"page.velseifHasNoCorrespondingIf":      "v-else-if directive requires a preceding v-if sibling. No matching v-if found.",
 	"page.cannotIterateOverTheVar":          "cannot iterate over the variable.",
+	"page.textContentNotSupported":          "text content is not supported here: <%s> has no text prop.",
+
+	"scheduler.recursiveUpdate": "maximum recursive updates exceeded: the watcher %s is triggered more than %d times in a flush, which may be changing a Ref it watches",
+
+	"tperr.unsupportedLocale": "unsupported locale: %s",
 }
 
+// translate a message type to the message of the current locale,
+// falling back to english if the current catalog doesn't contain it
 func T(msg string) string {
+	catalogMutex.RLock()
+	catalog := catalogs[locale]
+	catalogMutex.RUnlock()
+
+	if cnt, ok := catalog[msg]; ok {
+		return cnt
+	}
 	if cnt, ok := msgMap[msg]; ok {
 		return cnt
 	}
@@ -0,0 +1,141 @@
+package tperr
+
+// the sentinels of the error types, to be used with errors.Is:
+//
+//	if errors.Is(err, tperr.ErrPageUndefinedVariable) {
+//		...
+//	}
+//
+// errors.Is compares only the types, whatever the vars are. a sentinel can't be changed in place, as its fields are
+// unexported and Wrap returns a copy.
+var sentinels = map[string]bool{}
+
+func newSentinel(etype string) *TypedError {
+	sentinels[etype] = true
+	return NewTypedError(etype)
+}
+
+// get a new sentinel of an error type, or nil if there isn't one
+func Sentinel(etype string) *TypedError {
+	if !sentinels[etype] {
+		return nil
+	}
+	return NewTypedError(etype)
+}
+
+var (
+	ErrCssInvalidPropVal                = newSentinel("css.invalidPropVal")
+	ErrCssMismatchedCurlyBrace          = newSentinel("css.mismatchedCurlyBrace")
+	ErrCssMismatchedDoubleQuotationMark = newSentinel("css.mismatchedDoubleQuotationMark")
+	ErrCssMismatchedParenthesis         = newSentinel("css.mismatchedParenthesis")
+	ErrCssMismatchedSingleQuotationMark = newSentinel("css.mismatchedSingleQuotationMark")
+	ErrCssUnexpectedEnd                 = newSentinel("css.unexpectedEnd")
+	ErrCssUnexpectedToken               = newSentinel("css.unexpectedToken")
+	ErrCssUnsupportedProp               = newSentinel("css.unsupportedProp")
+	ErrCssUnterminatedComment           = newSentinel("css.unterminatedComment")
+
+	ErrExpExpectingElement              = newSentinel("exp.expectingElement")
+	ErrExpExpectingParameter            = newSentinel("exp.expectingParameter")
+	ErrExpIncompleteExpression          = newSentinel("exp.incompleteExpression")
+	ErrExpIntegerOverflow               = newSentinel("exp.integerOverflow")
+	ErrExpInvalidAssignmentTarget       = newSentinel("exp.invalidAssignmentTarget")
+	ErrExpInvalidFilter                 = newSentinel("exp.invalidFilter")
+	ErrExpInvalidMapEntry               = newSentinel("exp.invalidMapEntry")
+	ErrExpInvalidMapKey                 = newSentinel("exp.invalidMapKey")
+	ErrExpInvalidTenaryExpression       = newSentinel("exp.invalidTenaryExpression")
+	ErrExpMismatchedCurlyBrace          = newSentinel("exp.mismatchedCurlyBrace")
+	ErrExpMismatchedDoubleQuotationMark = newSentinel("exp.mismatchedDoubleQuotationMark")
+	ErrExpMismatchedParenthesis         = newSentinel("exp.mismatchedParenthesis")
+	ErrExpMismatchedSingleQuotationMark = newSentinel("exp.mismatchedSingleQuotationMark")
+	ErrExpMismatchedSquareBracket       = newSentinel("exp.mismatchedSquareBracket")
+	ErrExpStatementNotAllowed           = newSentinel("exp.statementNotAllowed")
+	ErrExpUnexpectedToken               = newSentinel("exp.unexpectedToken")
+
+	ErrTplConflictedDirective           = newSentinel("tpl.conflictedDirective")
+	ErrTplDuplicateAttribute            = newSentinel("tpl.duplicateAttribute")
+	ErrTplDuplicateDirective            = newSentinel("tpl.duplicateDirective")
+	ErrTplDuplicateEventHandler         = newSentinel("tpl.duplicateEventHandler")
+	ErrTplIncompleteTag                 = newSentinel("tpl.incompleteTag")
+	ErrTplInvalidDefAttr                = newSentinel("tpl.invalidDefAttr")
+	ErrTplInvalidDirective              = newSentinel("tpl.invalidDirective")
+	ErrTplInvalidVforDirective          = newSentinel("tpl.invalidVforDirective")
+	ErrTplMismatchedCurlyBrace          = newSentinel("tpl.mismatchedCurlyBrace")
+	ErrTplMismatchedDoubleQuotationMark = newSentinel("tpl.mismatchedDoubleQuotationMark")
+	ErrTplMismatchedSingleQuotationMark = newSentinel("tpl.mismatchedSingleQuotationMark")
+	ErrTplMismatchedTag                 = newSentinel("tpl.mismatchedTag")
+	ErrTplMissingClosingTag             = newSentinel("tpl.missingClosingTag")
+	ErrTplMissingOpeningTag             = newSentinel("tpl.missingOpeningTag")
+	ErrTplUnexpectedToken               = newSentinel("tpl.unexpectedToken")
+	ErrTplUnterminatedComment           = newSentinel("tpl.unterminatedComment")
+
+	ErrDdlInvalidStyleSection = newSentinel("ddl.invalidStyleSection")
+	ErrDdlWrapped             = newSentinel("ddl.wrapped")
+
+	ErrUtilGetStructFieldFieldNotExist       = newSentinel("util.GetStructField.fieldNotExist")
+	ErrUtilGetStructFieldUnavailableField    = newSentinel("util.GetStructField.unavailableField")
+	ErrUtilGetStructFieldUnexportedField     = newSentinel("util.GetStructField.unexportedField")
+	ErrUtilSetStructFieldCannotSetFieldValue = newSentinel("util.SetStructField.cannotSetFieldValue")
+	ErrUtilSetStructFieldFieldNotExist       = newSentinel("util.SetStructField.fieldNotExist")
+	ErrUtilSetStructFieldTypeMismatch        = newSentinel("util.SetStructField.typeMismatch")
+	ErrUtilSetStructFieldUnexportedField     = newSentinel("util.SetStructField.unexportedField")
+
+	ErrCalcArgumentNumberMismatch  = newSentinel("calc.argumentNumberMismatch")
+	ErrCalcArgumentNumberNotEnough = newSentinel("calc.argumentNumberNotEnough")
+	ErrCalcArgumentTypeMismatch    = newSentinel("calc.argumentTypeMismatch")
+	ErrCalcAssignmentTypeMismatch  = newSentinel("calc.assignmentTypeMismatch")
+	ErrCalcCannotAssign            = newSentinel("calc.cannotAssign")
+	ErrCalcDivisionByZero          = newSentinel("calc.divisionByZero")
+	ErrCalcEmptyFuncName           = newSentinel("calc.emptyFuncName")
+	ErrCalcEmptyVariableName       = newSentinel("calc.emptyVariableName")
+	ErrCalcExpMustBeFuncType       = newSentinel("calc.expMustBeFuncType")
+	ErrCalcExpMustBeVarType        = newSentinel("calc.expMustBeVarType")
+	ErrCalcFilterNotExist          = newSentinel("calc.filterNotExist")
+	ErrCalcFuncReturnedError       = newSentinel("calc.funcReturnedError")
+	ErrCalcIndexOutOfRange         = newSentinel("calc.indexOutOfRange")
+	ErrCalcIntegerOverflow         = newSentinel("calc.integerOverflow")
+	ErrCalcInvalidFilter           = newSentinel("calc.invalidFilter")
+	ErrCalcInvalidHelper           = newSentinel("calc.invalidHelper")
+	ErrCalcInvalidTernaryCondition = newSentinel("calc.invalidTernaryCondition")
+	ErrCalcMethodNotExist          = newSentinel("calc.methodNotExist")
+	ErrCalcOperandTypeMismatch     = newSentinel("calc.operandTypeMismatch")
+	ErrCalcUnsupportedArgument     = newSentinel("calc.unsupportedArgument")
+	ErrCalcUnsupportedOperator     = newSentinel("calc.unsupportedOperator")
+	ErrCalcVarIsNotFuncType        = newSentinel("calc.varIsNotFuncType")
+	ErrCalcVariableIsNotFunc       = newSentinel("calc.variableIsNotFunc")
+
+	ErrCompGetPropPropNotExist                 = newSentinel("comp.GetProp.propNotExist")
+	ErrCompSetEventHandlerEventNotSupported    = newSentinel("comp.SetEventHandler.eventNotSupported")
+	ErrCompSetPropPropNotAllowed               = newSentinel("comp.SetProp.propNotAllowed")
+	ErrCompSetPropPropSetterMustBeOneParameter = newSentinel("comp.SetProp.propSetterMustBeOneParameter")
+	ErrCompSetPropPropTypeMismatch             = newSentinel("comp.SetProp.propTypeMismatch")
+	ErrCompColorPropNotValid                   = newSentinel("comp.colorPropNotValid")
+	ErrCompTitleAlignNotValid                  = newSentinel("comp.titleAlignNotValid")
+
+	ErrPageCannotIterateOverTheVar          = newSentinel("page.cannotIterateOverTheVar")
+	ErrPageCannotResolveComponent           = newSentinel("page.cannotResolveComponent")
+	ErrPageCompNotFound                     = newSentinel("page.compNotFound")
+	ErrPageDirectiveNotFound                = newSentinel("page.directiveNotFound")
+	ErrPageInvalidDirective                 = newSentinel("page.invalidDirective")
+	ErrPageInvalidTypeOfComponentsField     = newSentinel("page.invalidTypeOfComponentsField")
+	ErrPageInvalidTypeOfDirectivesField     = newSentinel("page.invalidTypeOfDirectivesField")
+	ErrPageInvalidTypeOfFiltersField        = newSentinel("page.invalidTypeOfFiltersField")
+	ErrPageInvalidTypeOfMapOrderField       = newSentinel("page.invalidTypeOfMapOrderField")
+	ErrPageMainTemplateBeEssential          = newSentinel("page.mainTemplateBeEssential")
+	ErrPageTextContentNotSupported          = newSentinel("page.textContentNotSupported")
+	ErrPageTplFieldIsRequired               = newSentinel("page.tplFieldIsRequired")
+	ErrPageTplMustBeString                  = newSentinel("page.tplMustBeString")
+	ErrPageTplMustContainExactlyOneRootNode = newSentinel("page.tplMustContainExactlyOneRootNode")
+	ErrPageTplMustContainOneRootNode        = newSentinel("page.tplMustContainOneRootNode")
+	ErrPageUndefinedVariable                = newSentinel("page.undefinedVariable")
+	ErrPageVelseDirectiveMustBeBool         = newSentinel("page.velseDirectiveMustBeBool")
+	ErrPageVelseHasNoCorrespondingIf        = newSentinel("page.velseHasNoCorrespondingIf")
+	ErrPageVelseifDirectriveMustBeBool      = newSentinel("page.velseifDirectriveMustBeBool")
+	ErrPageVelseifHasNoCorrespondingIf      = newSentinel("page.velseifHasNoCorrespondingIf")
+	ErrPageVifDirectriveMustBeBool          = newSentinel("page.vifDirectriveMustBeBool")
+	ErrPageVmemoDirectiveMustBeArray        = newSentinel("page.vmemoDirectiveMustBeArray")
+	ErrPageVshowDirectiveMustBeBool         = newSentinel("page.vshowDirectiveMustBeBool")
+
+	ErrSchedulerRecursiveUpdate = newSentinel("scheduler.recursiveUpdate")
+
+	ErrTperrUnsupportedLocale = newSentinel("tperr.unsupportedLocale")
+)
@@ -66,6 +66,14 @@ func (tte *TraceableTypedError) SetPos(pos int) {
 	tte.pos = pos
 }
 
+func (tte *TraceableTypedError) Diagnostic() *Diagnostic {
+	if tte.pos < 0 || tte.raw == "" {
+		return NewDiagnostic("", -1, -1, SeverityError, tte.etype, tte.vars...)
+	}
+
+	return NewDiagnostic(tte.raw, tte.pos, tte.pos+1, SeverityError, tte.etype, tte.vars...)
+}
+
 func (tte *TraceableTypedError) IsKindOf(kind string) bool {
 	return strings.HasPrefix(tte.etype, kind)
 }
@@ -80,15 +88,3 @@ func NewTraceableTypedError(raw string, pos int, etype string, vars ...any) *Tra
 		pos: pos,
 	}
 }
-
-func IsErrorType(err error, etype string) bool {
-	if terr, ok := err.(*TypedError); ok {
-		return terr.Is(etype)
-	}
-
-	if terr, ok := err.(*TraceableTypedError); ok {
-		return terr.Is(etype)
-	}
-
-	return false
-}
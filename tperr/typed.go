@@ -1,11 +1,12 @@
 package tperr
 
 import (
+	"errors"
 	"fmt"
 )
 
 type Typed interface {
-	Is(etype string)
+	IsType(etype string) bool
 	GetEtype() string
 	GetVars() []any
 }
@@ -13,6 +14,7 @@ type Typed interface {
 type TypedError struct {
 	etype string
 	vars  []any
+	cause error
 }
 
 func NewTypedError(etype string, vars ...any) *TypedError {
@@ -26,10 +28,33 @@ func (te *TypedError) Error() string {
 	return fmt.Sprintf(T(te.etype), te.vars...)
 }
 
-func (te *TypedError) Is(etype string) bool {
+func (te *TypedError) IsType(etype string) bool {
 	return te.etype == etype
 }
 
+// make errors.Is(err, ErrXXX) match any error of the same type, whatever its vars are
+func (te *TypedError) Is(target error) bool {
+	typed, ok := target.(Typed)
+	return ok && typed.GetEtype() == te.etype
+}
+
+// make errors.As fill the detail structs, like *OperandTypeError
+func (te *TypedError) As(target any) bool {
+	return AsDetail(te.etype, te.vars, target)
+}
+
+// get a copy of the error which records the error causing it, leaving the error itself unchanged,
+// so that a sentinel can be wrapped too
+func (te *TypedError) Wrap(cause error) *TypedError {
+	wrapped := *te
+	wrapped.cause = cause
+	return &wrapped
+}
+
+func (te *TypedError) Unwrap() error {
+	return te.cause
+}
+
 func (te *TypedError) GetEtype() string {
 	return te.etype
 }
@@ -37,3 +62,15 @@ func (te *TypedError) GetEtype() string {
 func (te *TypedError) GetVars() []any {
 	return te.vars
 }
+
+// tell whether the error or any error it wraps is of the type
+func IsErrorType(err error, etype string) bool {
+	for err != nil {
+		if typed, ok := err.(Typed); ok && typed.IsType(etype) {
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package tperr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSentinels(t *testing.T) {
+	for etype := range msgMap {
+		if strings.Contains(etype, ".note.") {
+			continue
+		}
+		if Sentinel(etype) == nil {
+			t.Fatalf("no sentinel for %s", etype)
+		}
+	}
+	for etype := range sentinels {
+		if _, ok := msgMap[etype]; !ok {
+			t.Fatalf("no message for %s", etype)
+		}
+	}
+
+	if Sentinel("page.undefinedVariable") == Sentinel("page.undefinedVariable") {
+		t.Fatal("Sentinel should return a new error every time")
+	}
+
+	// wrapping a sentinel leaves it unchanged
+	wrapped := ErrPageUndefinedVariable.Wrap(ErrPageCompNotFound)
+	if wrapped == ErrPageUndefinedVariable || ErrPageUndefinedVariable.Unwrap() != nil {
+		t.Fatal("Wrap shouldn't change the sentinel")
+	}
+	if !errors.Is(wrapped, ErrPageUndefinedVariable) || !errors.Is(wrapped, ErrPageCompNotFound) {
+		t.Fatal("the wrapped sentinel should match both types")
+	}
+}
+
+func TestErrorsIsAs(t *testing.T) {
+	cause := NewTypedError("util.GetStructField.fieldNotExist", "Name")
+	err := fmt.Errorf("wrapped: %w", NewTypedError("page.undefinedVariable", "Name").Wrap(cause))
+
+	if !errors.Is(err, ErrPageUndefinedVariable) || !errors.Is(err, ErrUtilGetStructFieldFieldNotExist) {
+		t.Fatal("errors.Is should match the sentinels of the whole chain")
+	}
+	if errors.Is(err, ErrPageCompNotFound) {
+		t.Fatal("errors.Is shouldn't match the sentinel of another type")
+	}
+	if !IsErrorType(err, "util.GetStructField.fieldNotExist") {
+		t.Fatal("IsErrorType should walk the chain")
+	}
+
+	var verr *VariableError
+	if !errors.As(err, &verr) || verr.Name != "Name" || verr.GetEtype() != "page.undefinedVariable" {
+		t.Fatalf("unexpected variable error: %v", verr)
+	}
+	var ferr *FieldError
+	if !errors.As(err, &ferr) || ferr.Field != "Name" {
+		t.Fatalf("unexpected field error: %v", ferr)
+	}
+	var aerr *ArgumentCountError
+	if errors.As(err, &aerr) {
+		t.Fatal("errors.As shouldn't match a detail of another type")
+	}
+
+	err = NewTypedError("calc.argumentNumberNotEnough", "sum", 2, 1)
+	if !errors.As(err, &aerr) || aerr.Func != "sum" || aerr.Expected != 2 || aerr.Got != 1 || !aerr.Variadic {
+		t.Fatalf("unexpected argument count error: %v", aerr)
+	}
+	if aerr.Error() != err.Error() {
+		t.Fatalf("the detail should have the same message, got: %s", aerr.Error())
+	}
+}
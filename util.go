@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/TinyWisp/rview/tperr"
@@ -59,17 +60,47 @@ func SetStructField(structVar interface{}, field string, val interface{}) error
 	return nil
 }
 
+// the indexes of the struct fields, by the struct types and the field names.
+// a missing field is cached as well, since the variables of a page are looked up in its definition first.
+var structFieldIndexCache sync.Map
+
+type structFieldKey struct {
+	structType reflect.Type
+	field      string
+}
+
+func structFieldIndex(structType reflect.Type, field string) []int {
+	key := structFieldKey{structType, field}
+	if index, ok := structFieldIndexCache.Load(key); ok {
+		return index.([]int)
+	}
+
+	index := []int(nil)
+	if structField, ok := structType.FieldByName(field); ok {
+		index = structField.Index
+	}
+	structFieldIndexCache.Store(key, index)
+
+	return index
+}
+
 func GetStructField(structVar interface{}, field string) (interface{}, error) {
 	comp := reflect.ValueOf(structVar)
 	if comp.Kind() == reflect.Pointer {
 		comp = comp.Elem()
 	}
-	fieldVal := comp.FieldByName(field)
 
-	if !fieldVal.IsValid() {
+	index := structFieldIndex(comp.Type(), field)
+	if index == nil {
 		return nil, tperr.NewTypedError("util.GetStructField.fieldNotExist", field)
 	}
 
+	// a field promoted from a nil embedded pointer
+	fieldVal, err := comp.FieldByIndexErr(index)
+	if err != nil {
+		return nil, tperr.NewTypedError("util.GetStructField.unavailableField", field)
+	}
+
 	if !fieldVal.CanInterface() {
 		return nil, tperr.NewTypedError("util.GetStructField.unavailableField", field)
 	}
@@ -99,6 +130,10 @@ func sprintComponentNode(node *ComponentNode, level int) string {
 	str += fmt.Sprintln(spaces, "ElseIf: ", node.ElseIf)
 	str += fmt.Sprintln(spaces, "HasElse: ", node.HasElse)
 	str += fmt.Sprintln(spaces, "Else: ", node.Else)
+	str += fmt.Sprintln(spaces, "HasShow: ", node.HasShow)
+	str += fmt.Sprintln(spaces, "Show: ", node.Show)
+	str += fmt.Sprintln(spaces, "HasMemo: ", node.HasMemo)
+	str += fmt.Sprintln(spaces, "Memo: ", node.Memo)
 	str += fmt.Sprintln(spaces, "HasFor: ", node.HasFor)
 	str += fmt.Sprintln(spaces, "Ignore: ", node.Ignore)
 	str += fmt.Sprintln(spaces, "InheritVars: ", node.InheritVars)
@@ -0,0 +1,323 @@
+package rview
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// when the callback of a watcher runs after one of its sources is changed
+type WatchFlush int
+
+const (
+	// with the other watchers, which waits for the flush if there is a scheduler or a Batch
+	FlushPre WatchFlush = iota
+	// after the other watchers, like after a page is rendered again, which is what NextTick does
+	FlushPost
+	// as soon as the source is changed, on the goroutine changing it, even if there is a scheduler or a Batch
+	FlushSync
+)
+
+type WatchOptions struct {
+	// call the callback at once, with the current value as both the new value and the old value
+	Immediate bool
+	// watch the Refs and the reactive containers inside the value as well, and call the callback on any change of them,
+	// even if the value itself is the same
+	Deep bool
+	// stop watching after the callback is called once
+	Once bool
+	// call the callback only after the sources are left unchanged for the duration
+	Debounce time.Duration
+	// call the callback at most once in the duration, and once more at the end of it for the changes made meanwhile
+	Throttle time.Duration
+	Flush    WatchFlush
+}
+
+// the value of a Ref or a ReactiveStruct, which is read by a watcher
+type valueGetter interface {
+	getValue() interface{}
+}
+
+func (r *Ref[T]) getValue() interface{} {
+	return r.Get()
+}
+
+func (r *ReactiveStruct[T]) getValue() interface{} {
+	return r.Value()
+}
+
+// watch a Ref, and call fn with the new value and the old one when it is changed.
+// fn can register a cleanup by onCleanup, like canceling a request it starts,
+// which is called before fn is called again, or when the watcher is stopped.
+func Watch[T any](ref *Ref[T], fn func(newVal T, oldVal T, onCleanup func(cleanup func())), options WatchOptions) func() {
+	return watch(func() interface{} {
+		return ref.Get()
+	}, func(newVal interface{}, oldVal interface{}, onCleanup func(cleanup func())) {
+		fn(valueAs[T](newVal), valueAs[T](oldVal), onCleanup)
+	}, !options.Deep, options)
+}
+
+// the values of the two Refs watched by WatchSources2
+type Values2[A any, B any] struct {
+	V1 A
+	V2 B
+}
+
+// the values of the three Refs watched by WatchSources3
+type Values3[A any, B any, C any] struct {
+	V1 A
+	V2 B
+	V3 C
+}
+
+// watch two Refs at once, and call fn with their values when any of them is changed
+func WatchSources2[A any, B any](a *Ref[A], b *Ref[B], fn func(newVals Values2[A, B], oldVals Values2[A, B], onCleanup func(cleanup func())), options WatchOptions) func() {
+	return watch(func() interface{} {
+		return Values2[A, B]{V1: a.Get(), V2: b.Get()}
+	}, func(newVal interface{}, oldVal interface{}, onCleanup func(cleanup func())) {
+		fn(valueAs[Values2[A, B]](newVal), valueAs[Values2[A, B]](oldVal), onCleanup)
+	}, !options.Deep, options)
+}
+
+// watch three Refs at once, and call fn with their values when any of them is changed
+func WatchSources3[A any, B any, C any](a *Ref[A], b *Ref[B], c *Ref[C], fn func(newVals Values3[A, B, C], oldVals Values3[A, B, C], onCleanup func(cleanup func())), options WatchOptions) func() {
+	return watch(func() interface{} {
+		return Values3[A, B, C]{V1: a.Get(), V2: b.Get(), V3: c.Get()}
+	}, func(newVal interface{}, oldVal interface{}, onCleanup func(cleanup func())) {
+		fn(valueAs[Values3[A, B, C]](newVal), valueAs[Values3[A, B, C]](oldVal), onCleanup)
+	}, !options.Deep, options)
+}
+
+// watch several Refs or other sources at once, and call fn with their values when any of them is changed.
+// a source which has no value, like a Watchable of another package, is watched with nil as its value.
+// it is for more sources, or the sources which aren't Refs, as WatchSources2 and WatchSources3 give the values typed.
+func WatchSources(sources []Watchable, fn func(newVals []interface{}, oldVals []interface{}, onCleanup func(cleanup func())), options WatchOptions) func() {
+	// the values can only be compared if all the sources have them
+	compare := !options.Deep
+	for _, source := range sources {
+		if _, ok := source.(valueGetter); !ok {
+			compare = false
+		}
+	}
+
+	return watch(func() interface{} {
+		vals := make([]interface{}, len(sources))
+		for i, source := range sources {
+			if getter, ok := source.(valueGetter); ok {
+				vals[i] = getter.getValue()
+			} else {
+				trackWatchable(source)
+			}
+		}
+		return vals
+	}, func(newVal interface{}, oldVal interface{}, onCleanup func(cleanup func())) {
+		newVals, _ := newVal.([]interface{})
+		oldVals, _ := oldVal.([]interface{})
+		fn(newVals, oldVals, onCleanup)
+	}, compare, options)
+}
+
+// the watcher behind Watch and WatchSources, which reads the sources by get.
+// the callback is skipped if compare is set and the value is the same as the one given to the last call.
+func watch(get func() interface{}, fn func(newVal interface{}, oldVal interface{}, onCleanup func(cleanup func())), compare bool, options WatchOptions) func() {
+	mutex := sync.Mutex{}
+	latest := interface{}(nil)
+	last := interface{}(nil)
+	cleanup := (func())(nil)
+	timer := (*time.Timer)(nil)
+	lastCalled := time.Time{}
+	stopped := atomic.Bool{}
+
+	watcher := &Watcher{
+		id:        watcherCount.Add(1),
+		mutex:     &sync.Mutex{},
+		flushSync: options.Flush == FlushSync,
+		watchWhat: func() {
+			val := get()
+			if options.Deep {
+				traverse(reflect.ValueOf(val), map[uintptr]bool{})
+			}
+			mutex.Lock()
+			latest = val
+			mutex.Unlock()
+		},
+	}
+
+	stop := func() {
+		stopped.Store(true)
+		watcher.clean()
+
+		mutex.Lock()
+		fnCleanup := cleanup
+		cleanup = nil
+		if timer != nil {
+			timer.Stop()
+		}
+		mutex.Unlock()
+
+		if fnCleanup != nil {
+			fnCleanup()
+		}
+	}
+
+	call := func(force bool) {
+		if stopped.Load() {
+			return
+		}
+
+		mutex.Lock()
+		newVal, oldVal, fnCleanup := latest, last, cleanup
+		if compare && !force && isSameValue(newVal, oldVal) {
+			mutex.Unlock()
+			return
+		}
+		last, cleanup = newVal, nil
+		lastCalled = time.Now()
+		mutex.Unlock()
+
+		if fnCleanup != nil {
+			fnCleanup()
+		}
+		fn(newVal, oldVal, func(fnCleanup func()) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			cleanup = fnCleanup
+		})
+
+		if options.Once {
+			stop()
+		}
+	}
+
+	// the callback called later by a timer waits for the scheduler, if there is one
+	callLater := func(delay time.Duration) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(delay, func() {
+			NextTick(func() {
+				call(false)
+			})
+		})
+	}
+
+	schedule := func() {
+		switch {
+		case options.Debounce > 0:
+			callLater(options.Debounce)
+
+		case options.Throttle > 0:
+			mutex.Lock()
+			wait := options.Throttle - time.Since(lastCalled)
+			mutex.Unlock()
+			if wait <= 0 {
+				call(false)
+			} else {
+				callLater(wait)
+			}
+
+		case options.Flush == FlushPost:
+			NextTick(func() {
+				call(false)
+			})
+
+		default:
+			call(false)
+		}
+	}
+
+	watcher.doSomething = func(ref Watchable, newVal interface{}, oldVal interface{}) {
+		if stopped.Load() {
+			return
+		}
+
+		// the sources are read again, which watches the new nested values of a deep watcher
+		watcher.RunAndWatch()
+		schedule()
+	}
+
+	watcher.RunAndWatch()
+	mutex.Lock()
+	last = latest
+	mutex.Unlock()
+
+	if options.Immediate {
+		call(true)
+	}
+
+	return recordEffect(stop)
+}
+
+// watch a source by the watcher running on the current goroutine, without reading it
+func trackWatchable(source Watchable) {
+	activeWatcher := activeWatcherMgr.ActiveWatcher()
+	if activeWatcher != nil {
+		activeWatcher.AddRef(source)
+		source.AddWatcher(activeWatcher)
+	}
+}
+
+// read all the Refs and the reactive containers inside a value, so that a deep watcher watches them
+func traverse(val reflect.Value, seen map[uintptr]bool) {
+	if !val.IsValid() {
+		return
+	}
+
+	if val.CanInterface() {
+		switch v := val.Interface().(type) {
+		case valueGetter:
+			traverse(reflect.ValueOf(v.getValue()), seen)
+			return
+
+		case reactiveIterable:
+			for _, key := range v.partKeys() {
+				traverse(reflect.ValueOf(v.getPart(key)), seen)
+			}
+			return
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if val.IsNil() {
+			return
+		}
+		// a value reachable from itself is visited once
+		if seen[val.Pointer()] && val.Kind() != reflect.Slice {
+			return
+		}
+		seen[val.Pointer()] = true
+	}
+
+	switch val.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		traverse(val.Elem(), seen)
+
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			if val.Type().Field(i).IsExported() {
+				traverse(val.Field(i), seen)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			traverse(val.Index(i), seen)
+		}
+
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			traverse(iter.Value(), seen)
+		}
+	}
+}
+
+// a value as T, or the zero value of T if it is nil
+func valueAs[T any](val interface{}) T {
+	tval, _ := val.(T)
+	return tval
+}
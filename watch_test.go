@@ -0,0 +1,175 @@
+package rview
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type WatchProfile struct {
+	Name  string
+	Tags  *ReactiveSlice[string]
+	Score *Ref[int]
+}
+
+func TestWatchOptions(t *testing.T) {
+	profile := NewRef(&WatchProfile{Name: "a", Tags: NewReactiveSlice([]string{"x"}), Score: NewRef(1)})
+
+	// a deep watcher is triggered by the Refs and the containers inside the value
+	calls := []string{}
+	stopDeep := Watch(profile, func(newVal *WatchProfile, oldVal *WatchProfile, onCleanup func(cleanup func())) {
+		calls = append(calls, "deep")
+	}, WatchOptions{Deep: true})
+	defer stopDeep()
+	stopShallow := Watch(profile, func(newVal *WatchProfile, oldVal *WatchProfile, onCleanup func(cleanup func())) {
+		calls = append(calls, "shallow")
+	}, WatchOptions{})
+	defer stopShallow()
+
+	profile.Get().Score.Set(2)
+	profile.Get().Tags.Append("y")
+	profile.Set(&WatchProfile{Name: "b", Tags: NewReactiveSlice([]string{}), Score: NewRef(0)})
+	profile.Get().Score.Set(3)
+	if strings.Join(calls, ",") != "deep,deep,shallow,deep,deep" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	// onCleanup is called before the next call and when the watcher is stopped
+	a := NewRef(0)
+	calls = []string{}
+	stop := Watch(a, func(newVal int, oldVal int, onCleanup func(cleanup func())) {
+		calls = append(calls, fmt.Sprintf("%d->%d", oldVal, newVal))
+		onCleanup(func() {
+			calls = append(calls, fmt.Sprintf("cleanup %d", newVal))
+		})
+	}, WatchOptions{Immediate: true})
+	a.Set(1)
+	stop()
+	a.Set(2)
+	if strings.Join(calls, ",") != "0->0,cleanup 0,0->1,cleanup 1" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	// a watcher with Once stops after its first call
+	onces := 0
+	Watch(a, func(newVal int, oldVal int, onCleanup func(cleanup func())) {
+		onces += 1
+	}, WatchOptions{Once: true})
+	a.Set(3)
+	a.Set(4)
+	if onces != 1 {
+		t.Fatalf("the watcher is expected to be called once, got: %d", onces)
+	}
+
+	// with a scheduler, a sync watcher is called at once, and a post one after the others in the flush
+	flushes := []func(){}
+	SetScheduler(NewScheduler(func(flush func()) {
+		flushes = append(flushes, flush)
+	}))
+	defer SetScheduler(nil)
+
+	calls = []string{}
+	for _, flush := range []WatchFlush{FlushPost, FlushPre, FlushSync} {
+		name := map[WatchFlush]string{FlushPre: "pre", FlushPost: "post", FlushSync: "sync"}[flush]
+		defer Watch(a, func(newVal int, oldVal int, onCleanup func(cleanup func())) {
+			calls = append(calls, name)
+		}, WatchOptions{Flush: flush})()
+	}
+	a.Set(5)
+	if strings.Join(calls, ",") != "sync" {
+		t.Fatalf("only the sync watcher is expected to be called before the flush, got: %v", calls)
+	}
+	flushes[0]()
+	if strings.Join(calls, ",") != "sync,pre,post" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestWatchSources(t *testing.T) {
+	a, b := NewRef(1), NewRef("x")
+	calls := []string{}
+	stop := WatchSources([]Watchable{a, b}, func(newVals []interface{}, oldVals []interface{}, onCleanup func(cleanup func())) {
+		calls = append(calls, fmt.Sprintf("%v->%v", oldVals, newVals))
+	}, WatchOptions{})
+	defer stop()
+
+	a.Set(2)
+	Batch(func() {
+		b.Set("y")
+		a.Set(3)
+	})
+	// changed and changed back in a batch
+	Batch(func() {
+		a.Set(4)
+		a.Set(3)
+	})
+	if strings.Join(calls, ",") != "[1 x]->[2 x],[2 x]->[3 y]" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestWatchSourcesTyped(t *testing.T) {
+	a, b, c := NewRef(1), NewRef("x"), NewRef([]int{1})
+	calls := []string{}
+	stop2 := WatchSources2(a, b, func(newVals Values2[int, string], oldVals Values2[int, string], onCleanup func(cleanup func())) {
+		calls = append(calls, fmt.Sprintf("%d%s->%d%s", oldVals.V1, oldVals.V2, newVals.V1, newVals.V2))
+	}, WatchOptions{Immediate: true})
+	defer stop2()
+	stop3 := WatchSources3(a, b, c, func(newVals Values3[int, string, []int], oldVals Values3[int, string, []int], onCleanup func(cleanup func())) {
+		calls = append(calls, fmt.Sprintf("%d%s%v", newVals.V1, newVals.V2, newVals.V3))
+	}, WatchOptions{})
+	defer stop3()
+
+	a.Set(2)
+	Batch(func() {
+		b.Set("y")
+		a.Set(3)
+	})
+	// a value which isn't comparable, and is the same after it is changed
+	c.Set([]int{1})
+	c.Set([]int{2})
+	if strings.Join(calls, ",") != "1x->1x,1x->2x,2x[1],2x->3y,3y[1],3y[2]" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestWatchDebounceThrottle(t *testing.T) {
+	a := NewRef(0)
+	mutex := sync.Mutex{}
+	calls := map[string][]int{}
+	done := make(chan string, 10)
+	watch := func(name string, options WatchOptions, expect int) func() {
+		return Watch(a, func(newVal int, oldVal int, onCleanup func(cleanup func())) {
+			mutex.Lock()
+			calls[name] = append(calls[name], newVal)
+			count := len(calls[name])
+			mutex.Unlock()
+			if count == expect {
+				done <- name
+			}
+		}, options)
+	}
+	defer watch("debounce", WatchOptions{Debounce: 30 * time.Millisecond}, 1)()
+	defer watch("throttle", WatchOptions{Throttle: 30 * time.Millisecond}, 2)()
+
+	// the debounced watcher is called once for the last value,
+	// and the throttled one at once for the first value, and at the end of the duration for the last one
+	for i := 1; i <= 5; i++ {
+		a.Set(i)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the watchers are expected to be called")
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if fmt.Sprint(calls["debounce"]) != "[5]" || fmt.Sprint(calls["throttle"]) != "[1 5]" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}